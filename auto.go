@@ -0,0 +1,103 @@
+package gopv
+
+import (
+	"io"
+	"os"
+)
+
+const (
+	// AutoReporterLegendLog is the legend AutoReporter renders to outputs which are not terminals
+	AutoReporterLegendLog = "[{now}] - {done}/{total} done {percent_int}%%, RPS {rps_avg}, elapsed {elapsed}, ETA {eta}\n"
+	// AutoReporterDefaultLogEvery is the default number of reports per log line of AutoReporter
+	AutoReporterDefaultLogEvery = 10
+)
+
+// AutoReporter renders a progress bar in place when output is a terminal and periodic log lines
+// otherwise, e.g. in CI. The mode is selected on the first report
+type AutoReporter struct {
+	// config - should be copied in clone()
+	output   io.Writer
+	forceTTY *bool
+	logEvery int
+
+	// runtime vars. should not be copied in clone()
+	selected *TextReporter
+}
+
+// NewAuto is just a shortcut for New(total).WithReporter(NewAutoReporter())
+func NewAuto(total int) *Progress {
+	return New(total).WithReporter(NewAutoReporter())
+}
+
+// NewAutoReporter returns a new instance of reporter writing to stderr
+func NewAutoReporter() *AutoReporter {
+	return &AutoReporter{
+		output:   os.Stderr,
+		logEvery: AutoReporterDefaultLogEvery,
+	}
+}
+
+// WithOutput returns a new instance of AutoReporter with custom output
+func (r *AutoReporter) WithOutput(output io.Writer) *AutoReporter {
+	ret := r.clone()
+	ret.output = output
+	return ret
+}
+
+// WithForceTTY returns a new instance of AutoReporter which selects the mode as if output was
+// a terminal or not regardless of detection
+func (r *AutoReporter) WithForceTTY(tty bool) *AutoReporter {
+	ret := r.clone()
+	ret.forceTTY = &tty
+	return ret
+}
+
+// WithLogEvery returns a new instance of AutoReporter writing a log line every n reports when
+// output is not a terminal
+func (r *AutoReporter) WithLogEvery(n int) *AutoReporter {
+	ret := r.clone()
+	ret.logEvery = n
+	return ret
+}
+
+// Report passes report to the reporter of the selected mode
+func (r *AutoReporter) Report(report Report) {
+	if r.selected == nil {
+		r.selected = r.selectReporter()
+	}
+	r.selected.Report(report)
+}
+
+// Finalize finalizes the reporter of the selected mode
+func (r *AutoReporter) Finalize() {
+	if r.selected != nil {
+		r.selected.Finalize()
+	}
+}
+
+// selectReporter returns a progress bar reporter for terminals and a log line reporter otherwise
+func (r *AutoReporter) selectReporter() *TextReporter {
+	tty := isTerminal(r.output)
+	if r.forceTTY != nil {
+		tty = *r.forceTTY
+	}
+
+	if tty {
+		return NewTextReporter().
+			WithOutput(r.output).
+			WithLegend(TextReporterLegendProgressBar).
+			WithForceTTY(true)
+	}
+
+	return NewTextReporter().
+		WithOutput(r.output).
+		WithLegend(AutoReporterLegendLog).
+		WithLogEvery(r.logEvery).
+		WithForceTTY(false)
+}
+
+func (r *AutoReporter) clone() *AutoReporter {
+	cp := *r
+	cp.selected = nil
+	return &cp
+}
@@ -0,0 +1,58 @@
+package gopv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// autoReports returns reports of 120 items done by 10 every second
+func autoReports() []Report {
+	now := time.Date(2023, 12, 2, 8, 52, 24, 0, time.UTC)
+	var reports []Report
+	for i := 1; i <= 12; i++ {
+		reports = append(reports, NewReport(WithNow(now), WithDone(int64(i*10)), WithTotal(120),
+			WithElapsed(time.Duration(i)*time.Second)))
+	}
+	return reports
+}
+
+func TestAutoReporterTerminal(t *testing.T) {
+	var out bytes.Buffer
+	r := NewAutoReporter().WithOutput(&out).WithForceTTY(true).WithLogEvery(5)
+	for _, report := range autoReports() {
+		r.Report(report)
+	}
+	r.Finalize()
+
+	// every report is rendered in place
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\r\n"), "\r")
+	if len(lines) != 12 || strings.Contains(out.String(), "\n[") {
+		t.Fatalf("got %q", out.String())
+	}
+	if want := "[######"; !strings.HasPrefix(lines[0], want) {
+		t.Fatalf("first line %q is not a progress bar", lines[0])
+	}
+	if want := "] 100%, 10.00 RPS, 0s ETA"; !strings.HasSuffix(lines[11], want) {
+		t.Fatalf("last line %q, want it to end with %q", lines[11], want)
+	}
+}
+
+func TestAutoReporterLog(t *testing.T) {
+	var out bytes.Buffer
+	r := NewAutoReporter().WithOutput(&out).WithForceTTY(false).WithLogEvery(5)
+	for _, report := range autoReports() {
+		r.Report(report)
+	}
+	r.Finalize()
+
+	// the first, every 5th and the complete report are logged, no blank line is added at the end
+	want := "[2023-12-02 08:52:24] - 10/120 done 8%, RPS 10.00, elapsed 1s, ETA 11s\n" +
+		"[2023-12-02 08:52:24] - 60/120 done 50%, RPS 10.00, elapsed 6s, ETA 6s\n" +
+		"[2023-12-02 08:52:24] - 110/120 done 91%, RPS 10.00, elapsed 11s, ETA 1s\n" +
+		"[2023-12-02 08:52:24] - 120/120 done 100%, RPS 10.00, elapsed 12s, ETA 0s\n"
+	if got := out.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
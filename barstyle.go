@@ -0,0 +1,71 @@
+package gopv
+
+import "sync"
+
+// BarStyle defines characters the progress bar is drawn with. The width of the bar is measured in
+// columns, so cells drawn with wide characters take several columns
+type BarStyle struct {
+	// Fill is drawn in done cells
+	Fill string
+	// Head is drawn in the last done cell while progress is not complete. Empty means Fill
+	Head string
+	// Empty is drawn in remaining cells
+	Empty string
+	// Secondary is drawn in remaining cells up to the secondary position, see
+	// Progress.SetSecondary. Empty means the secondary position is not drawn
+	Secondary string
+	// Error is drawn in done cells of failed items, see Progress.AddError. Empty means failed
+	// items are drawn as done ones
+	Error string
+	// Left and Right delimit the bar. Empty strings mean no delimiters
+	Left  string
+	Right string
+}
+
+var (
+	// BarStyleASCII is the default style: [#####==---]
+	BarStyleASCII = BarStyle{Fill: "#", Secondary: "=", Empty: "-", Left: "[", Right: "]"}
+	// BarStyleBlocks draws the bar with block elements: │█████▓▓░░░│
+	BarStyleBlocks = BarStyle{Fill: "█", Secondary: "▓", Empty: "░", Left: "│", Right: "│"}
+	// BarStyleArrow draws the bar as an arrow: [====>--   ]
+	BarStyleArrow = BarStyle{Fill: "=", Head: ">", Secondary: "-", Empty: " ", Left: "[", Right: "]"}
+	// BarStyleDots draws the bar with dots: ●●●●●◦◦○○○
+	BarStyleDots = BarStyle{Fill: "●", Secondary: "◦", Empty: "○"}
+)
+
+var (
+	barStylesMu sync.RWMutex
+	barStyles   = map[string]BarStyle{
+		"ascii":  BarStyleASCII,
+		"blocks": BarStyleBlocks,
+		"arrow":  BarStyleArrow,
+		"dots":   BarStyleDots,
+	}
+)
+
+// cellWidth returns the number of columns a cell of the bar takes, which is the width of the widest
+// cell string. Cell strings are expected to have the same width
+func (s BarStyle) cellWidth() int {
+	width := 1
+	for _, cell := range []string{s.Fill, s.Head, s.Empty, s.Error, s.Secondary} {
+		if w := displayWidth(cell); w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// RegisterBarStyle adds a named bar style which can be selected by TextReporter.WithBarStyle
+func RegisterBarStyle(name string, style BarStyle) {
+	barStylesMu.Lock()
+	defer barStylesMu.Unlock()
+	barStyles[name] = style
+}
+
+// LookupBarStyle returns registered bar style by name
+func LookupBarStyle(name string) (BarStyle, bool) {
+	barStylesMu.RLock()
+	defer barStylesMu.RUnlock()
+	style, ok := barStyles[name]
+	return style, ok
+}
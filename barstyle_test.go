@@ -0,0 +1,51 @@
+package gopv
+
+import "testing"
+
+func TestBuiltinBarStyles(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "ascii", want: "[#####-----]"},
+		{name: "blocks", want: "│█████░░░░░│"},
+		{name: "arrow", want: "[====>     ]"},
+		{name: "dots", want: "●●●●●●○○○○○○"},
+	}
+	for _, tt := range tests {
+		r := NewTextReporter().WithLegend("{progress_bar}").WithProgressBarWidth(12).WithBarStyle(tt.name)
+		if got := r.RenderString(barReport(50, 0, 0)); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterBarStyle(t *testing.T) {
+	RegisterBarStyle("test-pipes", BarStyle{Fill: "|", Empty: ".", Left: "<", Right: ">"})
+	r := NewTextReporter().WithLegend("{progress_bar}").WithProgressBarWidth(12).WithBarStyle("test-pipes")
+	if got, want := r.RenderString(barReport(50, 0, 0)), "<|||||.....>"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("unknown style does not panic")
+		}
+	}()
+	NewTextReporter().WithBarStyle("no-such-style")
+}
+
+func TestMultiByteBarStyle(t *testing.T) {
+	// both are 3 bytes long, the first takes a single column and the second takes two
+	RegisterBarStyle("test-heavy", BarStyle{Fill: "━", Empty: "─", Left: "[", Right: "]"})
+	RegisterBarStyle("test-wide", BarStyle{Fill: "＝", Empty: "　", Left: "[", Right: "]"})
+	for _, style := range []string{"test-heavy", "test-wide"} {
+		r := NewTextReporter().WithLegend("{progress_bar}").WithProgressBarWidth(40).WithBarStyle(style)
+		for _, done := range []int64{0, 33, 50, 99, 100} {
+			bar := r.RenderString(barReport(done, 0, 0))
+			if got := displayWidth(bar); got != 40 {
+				t.Errorf("%s at %d%%: bar %q is %d columns wide, want 40", style, done, bar, got)
+			}
+		}
+	}
+}
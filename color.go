@@ -0,0 +1,79 @@
+package gopv
+
+import (
+	"image/color"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// colorMode is the color capability of the output terminal
+type colorMode int
+
+const (
+	colorModeNone colorMode = iota
+	colorMode256
+	colorModeTrueColor
+)
+
+// detectColorMode returns color capability of the terminal. ansi tells whether the output
+// supports escape sequences at all
+func detectColorMode(ansi bool) colorMode {
+	if !ansi {
+		return colorModeNone
+	}
+
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return colorModeTrueColor
+	}
+
+	return colorMode256
+}
+
+// interpolateColor returns color between from and to at position t in range [0, 1]
+func interpolateColor(from, to color.RGBA, t float64) color.RGBA {
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t + 0.5)
+	}
+
+	return color.RGBA{
+		R: lerp(from.R, to.R),
+		G: lerp(from.G, to.G),
+		B: lerp(from.B, to.B),
+		A: lerp(from.A, to.A),
+	}
+}
+
+// foregroundSequence returns escape sequence switching foreground color in given mode
+func foregroundSequence(c color.RGBA, mode colorMode) string {
+	switch mode {
+	case colorModeTrueColor:
+		return "\x1b[38;2;" + strconv.Itoa(int(c.R)) + ";" + strconv.Itoa(int(c.G)) + ";" + strconv.Itoa(int(c.B)) + "m"
+	case colorMode256:
+		return "\x1b[38;5;" + strconv.Itoa(nearest256(c)) + "m"
+	}
+	return ""
+}
+
+// resetSequence resets all colors and attributes
+const resetSequence = "\x1b[0m"
+
+// eraseLineSequence erases the line from the cursor to its end
+const eraseLineSequence = "\x1b[K"
+
+// nearest256 returns index of the nearest color in the 6x6x6 cube of the xterm 256-color palette
+func nearest256(c color.RGBA) int {
+	level := func(v uint8) int {
+		// cube levels are 0, 95, 135, 175, 215, 255
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return (int(v) - 35) / 40
+	}
+
+	return 16 + 36*level(c.R) + 6*level(c.G) + level(c.B)
+}
@@ -0,0 +1,47 @@
+package gopv
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// counterShard is a part of the sharded counter padded to a cache line, so shards updated by
+// different CPUs do not share cache lines
+type counterShard struct {
+	n int64
+	_ [56]byte
+}
+
+// shardedCounter spreads additions across shards to reduce contention of hot counters. Shards are
+// handed out by sync.Pool, which keeps them local to the processor in most cases
+type shardedCounter struct {
+	shards []counterShard
+	pool   sync.Pool
+	next   uint32
+}
+
+// newShardedCounter creates a counter with given number of shards
+func newShardedCounter(shards int) *shardedCounter {
+	s := &shardedCounter{shards: make([]counterShard, shards)}
+	s.pool.New = func() any {
+		i := atomic.AddUint32(&s.next, 1) % uint32(len(s.shards))
+		return &s.shards[i]
+	}
+	return s
+}
+
+// add adds n to one of the shards
+func (s *shardedCounter) add(n int64) {
+	shard := s.pool.Get().(*counterShard)
+	atomic.AddInt64(&shard.n, n)
+	s.pool.Put(shard)
+}
+
+// sum returns the sum of all shards
+func (s *shardedCounter) sum() int64 {
+	var sum int64
+	for i := range s.shards {
+		sum += atomic.LoadInt64(&s.shards[i].n)
+	}
+	return sum
+}
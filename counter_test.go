@@ -0,0 +1,114 @@
+package gopv
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// addConcurrently calls Add(1) n times from each of given number of goroutines
+func addConcurrently(pv *Progress, goroutines, n int) {
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				pv.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestShardedCounterSum(t *testing.T) {
+	s := newShardedCounter(4)
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				s.add(2)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := s.sum(); got != 32000 {
+		t.Errorf("sum = %d, want 32000", got)
+	}
+}
+
+func TestShardedCounterSet(t *testing.T) {
+	pv := New(1 << 20).WithReporter(NewNullReporter()).WithShardedCounter(8)
+	startManual(t, pv)
+
+	addConcurrently(pv, 16, 1000)
+	if got := pv.Snapshot().Done64; got != 16000 {
+		t.Fatalf("done after Add = %d, want 16000", got)
+	}
+
+	pv.Set(100)
+	if got := pv.Snapshot().Done64; got != 100 {
+		t.Fatalf("done after Set = %d, want 100", got)
+	}
+
+	addConcurrently(pv, 16, 1000)
+	pv.AddError(5)
+	report := pv.Snapshot()
+	if report.Done64 != 16105 || report.Errors != 5 {
+		t.Errorf("done = %d, errors = %d, want 16105 and 5", report.Done64, report.Errors)
+	}
+}
+
+func TestShardedCounterSetPhase(t *testing.T) {
+	clock := newManualClock()
+	pv := New(1 << 20).WithReporter(NewNullReporter()).WithClock(clock.Now).
+		WithShardedCounter(8).WithResetRateOnPhase(true)
+	startManual(t, pv)
+
+	addConcurrently(pv, 16, 1000)
+	clock.Advance(10 * time.Second)
+	pv.SetPhase("second")
+
+	addConcurrently(pv, 10, 100)
+	clock.Advance(10 * time.Second)
+
+	// the rate of the second phase counts only 1000 items added after the phase change
+	report := pv.Snapshot()
+	if report.Done64 != 17000 {
+		t.Errorf("done = %d, want 17000", report.Done64)
+	}
+	if report.RPSAvg != 100 {
+		t.Errorf("RPS = %v, want 100", report.RPSAvg)
+	}
+}
+
+// BenchmarkAddContended compares single and sharded counters under high goroutine contention.
+// Sharding pays off only with many CPUs hammering the same tracker; with few CPUs or rare calls
+// the single counter is faster, since sync.Pool costs a few nanoseconds per Add. Run it with
+// -cpu 1,8,32 on a machine with that many CPUs to see where the lines cross
+func BenchmarkAddContended(b *testing.B) {
+	shards := runtime.GOMAXPROCS(0)
+	benchmarks := []struct {
+		name string
+		pv   *Progress
+	}{
+		{"single", New(1 << 30)},
+		{"sharded", New(1 << 30).WithShardedCounter(shards)},
+	}
+	for _, bm := range benchmarks {
+		pv := bm.pv
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetParallelism(8)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					pv.Add(1)
+				}
+			})
+		})
+	}
+}
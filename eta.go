@@ -0,0 +1,129 @@
+package gopv
+
+import (
+	"math"
+	"time"
+)
+
+// ETAEstimator estimates time left to finish. Update is called by the progress tracker with
+// every report, Estimate is called right after it to fill Report.ETA
+type ETAEstimator interface {
+	Update(report Report)
+	Estimate() time.Duration
+}
+
+// LinearEstimator assumes that the remaining items are done at the average rate since start.
+// This is the default estimation of the progress tracker
+type LinearEstimator struct {
+	left int64
+	rps  float64
+}
+
+// NewLinearEstimator returns a new instance of linear estimator
+func NewLinearEstimator() *LinearEstimator {
+	return &LinearEstimator{}
+}
+
+// Update remembers items left and the average rate of given report
+func (e *LinearEstimator) Update(report Report) {
+	e.left = report.Left64
+	e.rps = report.RPSAvg
+}
+
+// Estimate returns time left at the average rate. Zero when the rate is unknown
+func (e *LinearEstimator) Estimate() time.Duration {
+	return linearETA(e.left, e.rps)
+}
+
+// linearETA returns time to do left items at given rate
+func linearETA(left int64, rps float64) time.Duration {
+	if rps == 0 {
+		return 0
+	}
+	return time.Duration(float64(left) / rps * float64(time.Second))
+}
+
+// TrendEstimator fits a line to instant rates of the recent reports, so ETA of a job which
+// steadily speeds up or slows down follows the trend instead of lagging behind the average rate
+type TrendEstimator struct {
+	window  int
+	samples []trendSample
+	left    int64
+}
+
+// trendSample is instant rate at some moment since start
+type trendSample struct {
+	at  float64
+	rps float64
+}
+
+// NewTrendEstimator returns a new instance of trend estimator fitting the last window reports.
+// Panics if window is less than 2
+func NewTrendEstimator(window int) *TrendEstimator {
+	if window < 2 {
+		panic("trend window should be at least 2")
+	}
+	return &TrendEstimator{window: window}
+}
+
+// Update remembers items left and instant rate of given report. Reports without a measured
+// instant rate are skipped
+func (e *TrendEstimator) Update(report Report) {
+	e.left = report.Left64
+	if report.DT <= 0 {
+		return
+	}
+
+	e.samples = append(e.samples, trendSample{at: report.Elapsed.Seconds(), rps: report.RPSInst})
+	if len(e.samples) > e.window {
+		e.samples = e.samples[len(e.samples)-e.window:]
+	}
+}
+
+// Estimate returns time to do items left if the rate keeps changing as in the window. If the rate
+// trends to zero before the work is done, the current rate is assumed to hold. Zero when the rate
+// is unknown
+func (e *TrendEstimator) Estimate() time.Duration {
+	if len(e.samples) == 0 || e.left <= 0 {
+		return 0
+	}
+
+	rps, slope := e.fit()
+	left := float64(e.left)
+
+	// solve left = rps*t + slope*t*t/2 for the nearest t > 0
+	disc := rps*rps + 2*slope*left
+	if disc >= 0 {
+		if denom := rps + math.Sqrt(disc); denom > 0 {
+			return time.Duration(2 * left / denom * float64(time.Second))
+		}
+	}
+
+	if rps <= 0 {
+		return 0
+	}
+	return time.Duration(left / rps * float64(time.Second))
+}
+
+// fit returns the rate at the last sample and its change per second by least squares
+func (e *TrendEstimator) fit() (rps, slope float64) {
+	n := float64(len(e.samples))
+	var sumT, sumR float64
+	for _, s := range e.samples {
+		sumT += s.at
+		sumR += s.rps
+	}
+	meanT, meanR := sumT/n, sumR/n
+
+	var cov, varT float64
+	for _, s := range e.samples {
+		cov += (s.at - meanT) * (s.rps - meanR)
+		varT += (s.at - meanT) * (s.at - meanT)
+	}
+	if varT > 0 {
+		slope = cov / varT
+	}
+
+	last := e.samples[len(e.samples)-1].at
+	return meanR + slope*(last-meanT), slope
+}
@@ -0,0 +1,123 @@
+package gopv
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLinearETA(t *testing.T) {
+	tests := []struct {
+		left int64
+		rps  float64
+		want time.Duration
+	}{
+		{left: 0, rps: 10, want: 0},
+		{left: 100, rps: 0, want: 0},
+		{left: 100, rps: 10, want: 10 * time.Second},
+		{left: 4, rps: 10, want: 400 * time.Millisecond},
+		{left: 1, rps: 3, want: 333333333 * time.Nanosecond},
+	}
+	for _, tt := range tests {
+		if got := linearETA(tt.left, tt.rps); got != tt.want {
+			t.Errorf("linearETA(%d, %v) = %v, want %v", tt.left, tt.rps, got, tt.want)
+		}
+	}
+}
+
+func TestLinearEstimator(t *testing.T) {
+	e := NewLinearEstimator()
+	if got := e.Estimate(); got != 0 {
+		t.Fatalf("estimate without reports = %v, want 0", got)
+	}
+
+	e.Update(NewReport(WithDone(30), WithTotal(120), WithElapsed(10*time.Second)))
+	if got := e.Estimate(); got != 30*time.Second {
+		t.Fatalf("estimate = %v, want 30s", got)
+	}
+}
+
+// stubEstimator returns a fixed estimate and signals the first update
+type stubEstimator struct {
+	updated chan Report
+}
+
+func (e *stubEstimator) Update(report Report) {
+	select {
+	case e.updated <- report:
+	default:
+	}
+}
+
+func (e *stubEstimator) Estimate() time.Duration {
+	return 42 * time.Second
+}
+
+func TestCustomEstimator(t *testing.T) {
+	e := &stubEstimator{updated: make(chan Report, 1)}
+	pv := New(100).WithReporter(NewNullReporter()).WithETAEstimator(e)
+	startManual(t, pv)
+
+	// the first report is made as soon as the tracker starts
+	report := <-e.updated
+	if report.Total != 100 {
+		t.Fatalf("estimator got report of %d items, want 100", report.Total)
+	}
+	if got := pv.Snapshot(); got.ETA != 42*time.Second || !got.FinishAt.Equal(got.Now.Add(42*time.Second)) {
+		t.Fatalf("ETA = %v, finish at %v, want 42s since %v", got.ETA, got.FinishAt, got.Now)
+	}
+}
+
+func TestTrendEstimator(t *testing.T) {
+	trend, linear := NewTrendEstimator(5), NewLinearEstimator()
+
+	// the job slows down by 10 items per second every second
+	var done int64
+	for i := 1; i <= 5; i++ {
+		rps := float64(110 - 10*i)
+		done += int64(rps)
+		report := NewReport(WithDone(done), WithTotal(1000), WithElapsed(time.Duration(i)*time.Second))
+		report.DT = time.Second
+		report.RPSInst = rps
+		trend.Update(report)
+		linear.Update(report)
+	}
+
+	// 600 items are left at 60 items per second slowing down to 10 after 5 more seconds: the rate
+	// trends to zero, so the current one is assumed to hold
+	if got := trend.Estimate(); got != 10*time.Second {
+		t.Fatalf("trend estimate = %v, want 10s", got)
+	}
+	if naive := linear.Estimate(); naive >= trend.Estimate() {
+		t.Fatalf("naive estimate %v is not earlier than trend %v", naive, trend.Estimate())
+	}
+}
+
+func TestCustomEstimatorDeadline(t *testing.T) {
+	tests := []struct {
+		deadline time.Duration
+		want     bool
+	}{
+		{deadline: time.Hour, want: true},
+		{deadline: 10 * time.Second, want: false},
+	}
+	for _, tt := range tests {
+		clock := newManualClock()
+		pv := New(100).WithReporter(NewNullReporter()).WithClock(clock.Now).
+			WithETAEstimator(&stubEstimator{}).WithDeadline(clock.Now().Add(tt.deadline))
+		reported := make(chan Report, 1)
+		pv.OnReport(func(report Report) {
+			select {
+			case reported <- report:
+			default:
+			}
+		})
+		startManual(t, pv)
+
+		// the very first report agrees with the estimate of 42s
+		report := <-reported
+		if report.ETA != 42*time.Second || report.WillFinish != tt.want {
+			t.Errorf("deadline in %v: ETA %v, WillFinish %v, want 42s and %v", tt.deadline, report.ETA,
+				report.WillFinish, tt.want)
+		}
+	}
+}
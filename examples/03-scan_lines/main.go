@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/pavel-krush/gopv"
+)
+
+func main() {
+	const total = 50
+
+	// external tool printing "Processed N" lines
+	cmd := exec.Command("sh", "-c", `for i in $(seq 1 `+strconv.Itoa(total)+`); do echo "Processed $i"; sleep 0.1; done`)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pv := gopv.NewTextWithLegend(total, gopv.TextReporterLegendProgressBar)
+	gopv.StartCtx(pv, ctx)
+
+	err = pv.ScanLines(stdout, func(line string) (int, bool) {
+		n, err := strconv.Atoi(strings.TrimPrefix(line, "Processed "))
+		return n, err == nil
+	})
+	if err == nil {
+		err = cmd.Wait()
+	}
+
+	cancel()
+	pv.Wait()
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
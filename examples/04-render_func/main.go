@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/pavel-krush/gopv"
+)
+
+func main() {
+	const total = 100
+
+	// barber pole: the filled part is striped, stripes move on every report
+	frame := 0
+	pole := func(cell int, cells int, ratio float64) rune {
+		if cell == 0 {
+			frame++
+		}
+		if float64(cell) >= ratio*float64(cells) {
+			return ' '
+		}
+		if (cell+frame)%4 < 2 {
+			return '/'
+		}
+		return '='
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pv := gopv.New(total).WithReporter(
+		gopv.NewTextReporter().
+			WithLegend(gopv.TextReporterLegendProgressBar).
+			WithProgressBarWidth(40).
+			WithRenderFunc(pole),
+	)
+	gopv.StartCtx(pv, ctx)
+
+	for i := 0; i < total; i++ {
+		<-time.After(time.Millisecond * 50)
+		pv.Add(1)
+	}
+	cancel()
+	pv.Wait()
+}
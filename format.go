@@ -0,0 +1,265 @@
+package gopv
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	siPrefixes = []string{"", "k", "M", "G", "T", "P", "E"}
+	iecUnits   = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	siUnits    = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+)
+
+// FormatBytes formats given number of bytes using binary (IEC) units, e.g. "1.5 MiB"
+func FormatBytes(n int64) string {
+	return formatBytes(float64(n), 1024, iecUnits)
+}
+
+// FormatBytesSI formats given number of bytes using decimal (SI) units, e.g. "1.5 MB"
+func FormatBytesSI(n int64) string {
+	return formatBytes(float64(n), 1000, siUnits)
+}
+
+// formatBytes scales n by base until it fits into the unit and formats it with one decimal.
+// Plain bytes are formatted without decimals
+func formatBytes(n float64, base float64, units []string) string {
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+
+	unit := 0
+	for n >= base && unit < len(units)-1 {
+		n /= base
+		unit++
+	}
+
+	if unit == 0 {
+		return sign + strconv.FormatFloat(n, 'f', 0, 64) + " " + units[unit]
+	}
+
+	return sign + strconv.FormatFloat(n, 'f', 1, 64) + " " + units[unit]
+}
+
+// FormatDurationCompact formats duration dropping zero units, e.g. "1h3s" instead of "1h0m3s".
+// Durations longer than a day are rendered with days ("1d2h"), durations under a second
+// are rounded to milliseconds ("350ms"), longer ones are rounded to seconds
+func FormatDurationCompact(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	if d < time.Second {
+		d = d.Round(time.Millisecond)
+		if d == 0 {
+			return "0s"
+		}
+		return sign + d.String()
+	}
+
+	d = d.Round(time.Second)
+	units := []struct {
+		size   time.Duration
+		suffix string
+	}{
+		{24 * time.Hour, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for _, unit := range units {
+		if n := d / unit.size; n > 0 {
+			sb.WriteString(strconv.FormatInt(int64(n), 10))
+			sb.WriteString(unit.suffix)
+			d -= n * unit.size
+		}
+	}
+
+	return sb.String()
+}
+
+// FormatRate formats rate per second with SI prefixes, e.g. "1.2k items/s". Empty unit
+// is omitted: "1.2k/s"
+func FormatRate(perSec float64, unit string) string {
+	sign := ""
+	if perSec < 0 {
+		sign = "-"
+		perSec = -perSec
+	}
+
+	prefix := 0
+	for perSec >= 1000 && prefix < len(siPrefixes)-1 {
+		perSec /= 1000
+		prefix++
+	}
+
+	value := strconv.FormatFloat(perSec, 'f', 1, 64)
+	if prefix == 0 {
+		value = strings.TrimSuffix(value, ".0")
+	}
+
+	if unit == "" {
+		return sign + value + siPrefixes[prefix] + "/s"
+	}
+	return sign + value + siPrefixes[prefix] + " " + unit + "/s"
+}
+
+// FormatSIRate formats rate of items per second with SI prefixes, e.g. "5.1M/s", "12.3k/s", "850/s"
+func FormatSIRate(perSec float64) string {
+	return FormatRate(perSec, "")
+}
+
+// FormatSummary formats a summary of the finished work according to the unit of the report,
+// e.g. "Done: 1 item in 3s", "Done: 10,000 items in 2m13s" or "Done: 1.5 MiB in 3s"
+func FormatSummary(report Report) string {
+	var done string
+	switch report.Unit {
+	case UnitBytes:
+		done = FormatBytes(report.Done64)
+	case UnitDuration:
+		done = FormatDurationCompact(time.Duration(report.Done64))
+	default:
+		done = groupDigits(report.Done64) + " " + pluralize(report.Done64, "item", "items")
+	}
+	return "Done: " + done + " in " + FormatDurationCompact(report.Elapsed)
+}
+
+// groupDigits formats integer with thousands separated by commas, e.g. "10,000"
+func groupDigits(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for i := 0; i < len(digits); i++ {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteByte(digits[i])
+	}
+	return sb.String()
+}
+
+// pluralize returns singular form for one and plural form otherwise
+func pluralize(n int64, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
+// FormatFuzzyDuration formats duration in natural language, e.g. "a few seconds", "about a minute",
+// "about 5 minutes", "over an hour"
+func FormatFuzzyDuration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+
+	about := func(n time.Duration, unit string) string {
+		return "about " + strconv.FormatInt(int64(n), 10) + " " + unit
+	}
+
+	switch {
+	case d < 10*time.Second:
+		return "a few seconds"
+	case d < 45*time.Second:
+		return "less than a minute"
+	case d < 90*time.Second:
+		return "about a minute"
+	case d < 45*time.Minute:
+		return about(d.Round(time.Minute)/time.Minute, "minutes")
+	case d < time.Hour:
+		return "about an hour"
+	case d < 90*time.Minute:
+		return "over an hour"
+	case d < 22*time.Hour:
+		return about(d.Round(time.Hour)/time.Hour, "hours")
+	case d < 36*time.Hour:
+		return "about a day"
+	default:
+		return about(d.Round(24*time.Hour)/(24*time.Hour), "days")
+	}
+}
+
+// TruncateMode selects which part of a string TruncateString elides
+type TruncateMode int
+
+const (
+	// TruncateNone keeps the string as is
+	TruncateNone TruncateMode = iota
+	// TruncateStart elides the beginning, e.g. ".../dir/file.txt"
+	TruncateStart
+	// TruncateMiddle elides the middle keeping both ends, e.g. "/very/.../file.txt"
+	TruncateMiddle
+	// TruncateEnd elides the end, e.g. "/very/long/..."
+	TruncateEnd
+)
+
+// truncateEllipsis replaces the elided part of a truncated string
+const truncateEllipsis = "..."
+
+// TruncateString shortens s to width display columns replacing the elided part with "...".
+// Wide characters take two columns and are never cut in half. Strings not wider than width are
+// returned as is
+func TruncateString(s string, width int, mode TruncateMode) string {
+	if mode == TruncateNone || displayWidth(s) <= width {
+		return s
+	}
+
+	runes := []rune(s)
+	keep := width - len(truncateEllipsis)
+	if keep <= 0 {
+		// no room for the ellipsis
+		if width <= 0 {
+			return ""
+		}
+		return headColumns(runes, width)
+	}
+
+	switch mode {
+	case TruncateStart:
+		return truncateEllipsis + tailColumns(runes, keep)
+	case TruncateMiddle:
+		head := keep - keep/2
+		return headColumns(runes, head) + truncateEllipsis + tailColumns(runes, keep/2)
+	default:
+		return headColumns(runes, keep) + truncateEllipsis
+	}
+}
+
+// headColumns returns the longest beginning of runes fitting into width columns
+func headColumns(runes []rune, width int) string {
+	n := 0
+	for _, r := range runes {
+		width -= runeWidth(r)
+		if width < 0 {
+			break
+		}
+		n++
+	}
+	return string(runes[:n])
+}
+
+// tailColumns returns the longest ending of runes fitting into width columns
+func tailColumns(runes []rune, width int) string {
+	start := len(runes)
+	for start > 0 {
+		width -= runeWidth(runes[start-1])
+		if width < 0 {
+			break
+		}
+		start--
+	}
+	return string(runes[start:])
+}
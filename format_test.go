@@ -0,0 +1,179 @@
+package gopv
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTruncateString(t *testing.T) {
+	const path = "/very/long/path/to/some/file.txt"
+	tests := []struct {
+		s     string
+		width int
+		mode  TruncateMode
+		want  string
+	}{
+		{s: path, width: 20, mode: TruncateNone, want: path},
+		{s: path, width: 40, mode: TruncateEnd, want: path},
+		{s: path, width: 20, mode: TruncateStart, want: ".../to/some/file.txt"},
+		{s: path, width: 20, mode: TruncateMiddle, want: "/very/lon...file.txt"},
+		{s: path, width: 20, mode: TruncateEnd, want: "/very/long/path/t..."},
+		{s: path, width: 2, mode: TruncateEnd, want: "/v"},
+		{s: path, width: 0, mode: TruncateEnd, want: ""},
+
+		// wide characters take two columns and are not cut in half
+		{s: "文件名字很长的文件.txt", width: 10, mode: TruncateEnd, want: "文件名..."},
+		{s: "文件名字很长的文件.txt", width: 11, mode: TruncateEnd, want: "文件名字..."},
+		{s: "文件名字很长的文件.txt", width: 10, mode: TruncateStart, want: "...件.txt"},
+		{s: "文件名字很长的文件.txt", width: 15, mode: TruncateMiddle, want: "文件名...件.txt"},
+		{s: "🚀🚀🚀🚀🚀🚀", width: 8, mode: TruncateEnd, want: "🚀🚀..."},
+		{s: "🚀🚀", width: 4, mode: TruncateEnd, want: "🚀🚀"},
+	}
+	for _, tt := range tests {
+		got := TruncateString(tt.s, tt.width, tt.mode)
+		if got != tt.want {
+			t.Errorf("TruncateString(%q, %d, %d) = %q, want %q", tt.s, tt.width, tt.mode, got, tt.want)
+		}
+		if w := displayWidth(got); w > tt.width && tt.mode != TruncateNone {
+			t.Errorf("TruncateString(%q, %d, %d) is %d columns wide", tt.s, tt.width, tt.mode, w)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n       int64
+		wantIEC string
+		wantSI  string
+	}{
+		{n: 0, wantIEC: "0 B", wantSI: "0 B"},
+		{n: 999, wantIEC: "999 B", wantSI: "999 B"},
+		{n: 1000, wantIEC: "1000 B", wantSI: "1.0 kB"},
+		{n: 1023, wantIEC: "1023 B", wantSI: "1.0 kB"},
+		{n: 1024, wantIEC: "1.0 KiB", wantSI: "1.0 kB"},
+		{n: 1000000, wantIEC: "976.6 KiB", wantSI: "1.0 MB"},
+		{n: 1 << 20, wantIEC: "1.0 MiB", wantSI: "1.0 MB"},
+		{n: 1 << 30, wantIEC: "1.0 GiB", wantSI: "1.1 GB"},
+		{n: -1024, wantIEC: "-1.0 KiB", wantSI: "-1.0 kB"},
+	}
+	for _, tt := range tests {
+		if got := FormatBytes(tt.n); got != tt.wantIEC {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.n, got, tt.wantIEC)
+		}
+		if got := FormatBytesSI(tt.n); got != tt.wantSI {
+			t.Errorf("FormatBytesSI(%d) = %q, want %q", tt.n, got, tt.wantSI)
+		}
+	}
+}
+
+func TestFormatDurationCompact(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: time.Hour + 3*time.Second, want: "1h3s"},
+		{d: 0, want: "0s"},
+		{d: 26 * time.Hour, want: "1d2h"},
+		{d: 350 * time.Millisecond, want: "350ms"},
+		{d: 100 * time.Microsecond, want: "0s"},
+		{d: 90*time.Second + 400*time.Millisecond, want: "1m30s"},
+		{d: 48*time.Hour + time.Minute, want: "2d1m"},
+		{d: -5 * time.Second, want: "-5s"},
+	}
+	for _, tt := range tests {
+		if got := FormatDurationCompact(tt.d); got != tt.want {
+			t.Errorf("FormatDurationCompact(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+
+	report := NewReport(WithDone(1), WithTotal(2), WithElapsed(time.Hour+3*time.Second))
+	got := NewTextReporter().WithLegend("{elapsed_compact} {eta_compact}").RenderString(report)
+	if want := "1h3s 1h3s"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatRate(t *testing.T) {
+	tests := []struct {
+		perSec float64
+		unit   string
+		want   string
+	}{
+		{perSec: 0, unit: "items", want: "0 items/s"},
+		{perSec: 850, unit: "items", want: "850 items/s"},
+		{perSec: 1200, unit: "items", want: "1.2k items/s"},
+		{perSec: 5.1e6, unit: "", want: "5.1M/s"},
+		{perSec: -1500, unit: "rows", want: "-1.5k rows/s"},
+	}
+	for _, tt := range tests {
+		if got := FormatRate(tt.perSec, tt.unit); got != tt.want {
+			t.Errorf("FormatRate(%v, %q) = %q, want %q", tt.perSec, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSIRate(t *testing.T) {
+	tests := []struct {
+		perSec float64
+		want   string
+	}{
+		{perSec: 0.5, want: "0.5/s"},
+		{perSec: 850, want: "850/s"},
+		{perSec: 12345, want: "12.3k/s"},
+		{perSec: 5123456, want: "5.1M/s"},
+		{perSec: 7.2e9, want: "7.2G/s"},
+	}
+	for _, tt := range tests {
+		if got := FormatSIRate(tt.perSec); got != tt.want {
+			t.Errorf("FormatSIRate(%v) = %q, want %q", tt.perSec, got, tt.want)
+		}
+	}
+}
+
+func TestFormatFuzzyDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 0, want: "a few seconds"},
+		{d: 9 * time.Second, want: "a few seconds"},
+		{d: 30 * time.Second, want: "less than a minute"},
+		{d: 58 * time.Second, want: "about a minute"},
+		{d: 118 * time.Second, want: "about 2 minutes"},
+		{d: 5 * time.Minute, want: "about 5 minutes"},
+		{d: 50 * time.Minute, want: "about an hour"},
+		{d: 75 * time.Minute, want: "over an hour"},
+		{d: 5*time.Hour + 20*time.Minute, want: "about 5 hours"},
+		{d: 30 * time.Hour, want: "about a day"},
+		{d: 80 * time.Hour, want: "about 3 days"},
+	}
+	for _, tt := range tests {
+		if got := FormatFuzzyDuration(tt.d); got != tt.want {
+			t.Errorf("FormatFuzzyDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSummary(t *testing.T) {
+	tests := []struct {
+		report Report
+		want   string
+	}{
+		{report: NewReport(WithDone(1), WithTotal(1), WithElapsed(3*time.Second)), want: "Done: 1 item in 3s"},
+		{report: NewReport(WithDone(0), WithTotal(1), WithElapsed(time.Second)), want: "Done: 0 items in 1s"},
+		{
+			report: NewReport(WithDone(10000), WithTotal(10000), WithElapsed(2*time.Minute+13*time.Second)),
+			want:   "Done: 10,000 items in 2m13s",
+		},
+		{
+			report: NewReport(WithDone(1536*1024), WithTotal(1536*1024), WithElapsed(3*time.Second),
+				WithReportUnit(UnitBytes)),
+			want: "Done: 1.5 MiB in 3s",
+		},
+	}
+	for _, tt := range tests {
+		if got := FormatSummary(tt.report); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
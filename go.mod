@@ -1,3 +1,8 @@
 module github.com/pavel-krush/gopv
 
 go 1.18
+
+require (
+	golang.org/x/sys v0.5.0
+	golang.org/x/term v0.5.0
+)
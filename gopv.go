@@ -2,36 +2,165 @@ package gopv
 
 import (
 	"context"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 )
 
 type Progress struct {
-	total            int64
-	done             int64
-	startedAt        time.Time
+	// counters holds *counters. They are replaced as a whole, so reports never see a total
+	// from one moment and errors or phase from another
+	counters     atomic.Value
+	queueDepth   int64
+	lastActivity int64
+	workers      int64
+	// done is kept apart from counters, so Add is a single atomic operation. loadCounters
+	// reads it consistently with counters
+	done int64
+	// loop holds *loopState. It is replaced only by the reporter loop, so Snapshot can read it
+	// from any goroutine
+	loop             atomic.Value
+	resumedElapsed   time.Duration
+	deadline         time.Time
+	label            string
+	heartbeat        time.Duration
 	reportTime       time.Duration
-	lastReportedDone int64
-	lastReportedAt   time.Time
+	rateLimit        float64
+	etaEstimator     ETAEstimator
+	clock            func() time.Time
+	skipFirstReport  bool
+	unit             string
+	sampleRate       time.Duration
+	refreshOnAdd     time.Duration
+	resetRateOnPhase bool
+	totalFloor       bool
+	sharded          *shardedCounter
+	rpsStdDev        runningStdDev
+	history          *rateHistory
+
+	// source overrides counters, it is used to aggregate counters of other trackers
+	source func() *counters
 
 	reporter Reporter
+	onReport []func(Report)
 	doneCh   chan struct{}
+	stopCh   chan struct{}
+	changeCh chan struct{}
+	redrawCh chan struct{}
+	started  int32
+	stopped  int32
+}
+
+// counters is an immutable set of progress counters
+type counters struct {
+	// done is filled by loadCounters, stored counters do not use it
+	done   int64
+	total  int64
+	errors int64
+	// secondary is the secondary position, e.g. buffered items, see SetSecondary
+	secondary int64
+
+	phase          string
+	message        string
+	phaseStartedAt time.Time
+	phaseStartDone int64
+
+	// pausedAt is the start of the current pause, zero when not paused
+	pausedAt time.Time
+	// pausedFor is the total duration of finished pauses
+	pausedFor time.Duration
+
+	completed bool
+}
+
+// loopState is an immutable state of the reporter loop which reports are derived from
+type loopState struct {
+	startedAt        time.Time
+	window           *rateWindow
+	lastReportedAt   time.Time
+	lastReportedDone int64
+	rpsStdDev        float64
+	reportCount      int
+	// eta is the last estimate of the custom ETA estimator
+	eta time.Duration
 }
 
 var DefaultReportTime = time.Second
 
+// TotalFloorMaxRatio is the maximal ratio of trackers with total floor until they are completed,
+// see WithTotalFloor
+const TotalFloorMaxRatio = 0.99
+
+// Units of done and total items, see WithUnit
+const (
+	// UnitItems is the default unit
+	UnitItems = "items"
+	// UnitBytes is the unit of progress trackers counting bytes
+	UnitBytes = "bytes"
+	// UnitDuration is the unit of progress trackers counting time, see NewDurationTotal
+	UnitDuration = "duration"
+)
+
 // New creates new progress tracker
 func New(total int) *Progress {
 	if total <= 0 {
 		panic("total should be greater than 0")
 	}
 
-	return &Progress{
-		total:      int64(total),
+	p := NewIndeterminate()
+	p.counters.Store(&counters{total: int64(total)})
+
+	return p
+}
+
+// NewInt64 creates new progress tracker with int64 total. Use it for totals which may not fit
+// into int on 32-bit platforms, e.g. large byte counts, and read Report.Done64 and Report.Total64
+func NewInt64(total int64) *Progress {
+	if total <= 0 {
+		panic("total should be greater than 0")
+	}
+
+	p := NewIndeterminate()
+	p.counters.Store(&counters{total: total})
+
+	return p
+}
+
+// NewIndeterminate creates new progress tracker for work of unknown size. Its reports have zero
+// total, ratio and ETA until total is set with SetTotal
+func NewIndeterminate() *Progress {
+	p := &Progress{
 		reportTime: DefaultReportTime,
 		reporter:   NewTextReporter(),
 		doneCh:     make(chan struct{}),
+		stopCh:     make(chan struct{}),
+		changeCh:   make(chan struct{}, 1),
+		redrawCh:   make(chan struct{}, 1),
+		unit:       UnitItems,
 	}
+	p.counters.Store(&counters{})
+	p.loop.Store(&loopState{})
+
+	return p
+}
+
+// NewDurationTotal creates new progress tracker for work measured in time rather than items,
+// e.g. transcoding 2h of video. Progress is advanced by AddDuration, done and total are
+// rendered as durations
+func NewDurationTotal(total time.Duration) *Progress {
+	if total <= 0 {
+		panic("total should be greater than 0")
+	}
+
+	p := NewIndeterminate()
+	p.unit = UnitDuration
+	p.counters.Store(&counters{total: int64(total)})
+
+	return p
 }
 
 // NewTextWithLegend is just a shortcut for
@@ -47,77 +176,801 @@ func (p *Progress) WithReporter(r Reporter) *Progress {
 	return &cp
 }
 
-// StartCtx starts progress tracker using context
-func StartCtx(p *Progress, ctx context.Context) {
-	StartChan(p, ctx.Done())
+// WithRateLimit returns a new instance of progress tracker for a workload throttled to perSec
+// items per second. ETA is computed from the limit instead of the observed rate
+func (p *Progress) WithRateLimit(perSec float64) *Progress {
+	cp := *p
+	cp.rateLimit = perSec
+	return &cp
+}
+
+// WithClock returns a new instance of progress tracker using given function as a source of current time
+func (p *Progress) WithClock(now func() time.Time) *Progress {
+	cp := *p
+	cp.clock = now
+	return &cp
+}
+
+// WithUnit returns a new instance of progress tracker counting items in given unit. The unit is
+// passed in every report, so consumers know how to interpret done and total. Use UnitItems,
+// UnitBytes or a custom label
+func (p *Progress) WithUnit(unit string) *Progress {
+	cp := *p
+	cp.unit = unit
+	return &cp
+}
+
+// WithSampleRate returns a new instance of progress tracker sampling done items every d, which
+// should be shorter than the report interval. Instant rate is then measured over the last report
+// interval from fine samples instead of the two last reports, while reports are still rendered
+// once per report interval
+func (p *Progress) WithSampleRate(d time.Duration) *Progress {
+	cp := *p
+	cp.sampleRate = d
+	return &cp
+}
+
+// WithDeadline returns a new instance of progress tracker which is expected to finish by t.
+// Reports tell whether the work will finish in time, see Report.WillFinish. StartCtx uses the
+// deadline of its context unless a deadline is set explicitly
+func (p *Progress) WithDeadline(t time.Time) *Progress {
+	cp := *p
+	cp.deadline = t
+	return &cp
+}
+
+// WithHeartbeat returns a new instance of progress tracker which reports inactivity when no items
+// are added or set for d, see Report.NoActivity. It tells hung jobs from slow ones
+func (p *Progress) WithHeartbeat(d time.Duration) *Progress {
+	cp := *p
+	cp.heartbeat = d
+	return &cp
+}
+
+// WithLabel returns a new instance of progress tracker labeling its reports, see Report.Label
+func (p *Progress) WithLabel(label string) *Progress {
+	cp := *p
+	cp.label = label
+	return &cp
+}
+
+// WithRefreshOnAdd returns a new instance of progress tracker which reports once progress has not
+// changed for debounce, so bursts of updates are coalesced into a single report. Periodic reports
+// are made as usual
+func (p *Progress) WithRefreshOnAdd(debounce time.Duration) *Progress {
+	cp := *p
+	cp.refreshOnAdd = debounce
+	return &cp
+}
+
+// WithRateHistory returns a new instance of progress tracker keeping instant rates of the last n
+// reports, see RateHistory
+func (p *Progress) WithRateHistory(n int) *Progress {
+	if n <= 0 {
+		panic("rate history size should be greater than 0")
+	}
+
+	cp := *p
+	cp.history = &rateHistory{samples: make([]float64, n)}
+	return &cp
+}
+
+// RateHistory returns instant rates of the last reports from the oldest to the newest one.
+// It is empty unless the progress tracker was created with WithRateHistory
+func (p *Progress) RateHistory() []float64 {
+	if p.history == nil {
+		return nil
+	}
+	return p.history.values()
+}
+
+// WithSkipInitialReport returns a new instance of progress tracker which does not report right
+// after start, so the first report is rendered after one report interval
+func (p *Progress) WithSkipInitialReport(skip bool) *Progress {
+	cp := *p
+	cp.skipFirstReport = skip
+	return &cp
+}
+
+// WithETAEstimator returns a new instance of progress tracker estimating ETA with given estimator.
+// The estimator takes precedence over WithRateLimit. By default ETA is estimated linearly,
+// see LinearEstimator
+func (p *Progress) WithETAEstimator(e ETAEstimator) *Progress {
+	cp := *p
+	cp.etaEstimator = e
+	return &cp
+}
+
+// WithResetRateOnPhase returns a new instance of progress tracker which measures rates and ETA
+// since the last phase change, so the previous phase does not affect the current one
+func (p *Progress) WithResetRateOnPhase(reset bool) *Progress {
+	cp := *p
+	cp.resetRateOnPhase = reset
+	return &cp
 }
 
-// StartChan starts progress tracker using done channel
-func StartChan[T any](p *Progress, done <-chan T) {
-	p.startedAt = time.Now()
-	p.lastReportedAt = p.startedAt
+// WithShardedCounter returns a new instance of progress tracker spreading Add across given number of
+// counter shards, which are summed on report. It helps only when Add is called from many goroutines
+// on many CPUs at a very high rate, e.g. millions of times per second. Usually the number of CPUs
+// is a good number of shards
+func (p *Progress) WithShardedCounter(shards int) *Progress {
+	if shards <= 0 {
+		panic("number of shards should be greater than 0")
+	}
+
+	cp := *p
+	cp.sharded = newShardedCounter(shards)
+	return &cp
+}
+
+// WithTotalFloor returns a new instance of progress tracker treating total as a lower bound, e.g.
+// when more items are discovered while working. The work is not complete until Complete is called
+// and the ratio is capped at TotalFloorMaxRatio. SetTotal raises the floor and never lowers it
+func (p *Progress) WithTotalFloor(floor bool) *Progress {
+	cp := *p
+	cp.totalFloor = floor
+	return &cp
+}
+
+// Tracker is a progress tracker which can be started by StartCtx and StartChan.
+// It is implemented by Progress and MultiProgress
+type Tracker interface {
+	progress() *Progress
+}
+
+func (p *Progress) progress() *Progress {
+	return p
+}
+
+// labelKey is the type of LabelKey
+type labelKey struct{}
+
+// LabelKey is the context key of the progress tracker label, e.g. a request ID. StartCtx labels
+// reports with the string stored under this key unless the label is set with WithLabel:
+//
+//	ctx = context.WithValue(ctx, gopv.LabelKey, requestID)
+var LabelKey = labelKey{}
+
+// StartCtx starts progress tracker using context. Deadline and label of the context are used
+// unless set explicitly, see WithDeadline and LabelKey
+func StartCtx(t Tracker, ctx context.Context) {
+	p := t.progress()
+	if label, ok := ctx.Value(LabelKey).(string); ok && p.label == "" {
+		p.label = label
+	}
+	if deadline, ok := ctx.Deadline(); ok && p.deadline.IsZero() {
+		p.deadline = deadline
+	}
+	StartChan(t, ctx.Done())
+}
+
+// StartChan starts progress tracker using done channel.
+// Progress tracker can be started only once, subsequent calls panic
+func StartChan[T any](t Tracker, done <-chan T) {
+	p := t.progress()
+	if !atomic.CompareAndSwapInt32(&p.started, 0, 1) {
+		panic("progress already started")
+	}
+
+	now := p.now()
+	state := &loopState{
+		startedAt:        now.Add(-p.resumedElapsed),
+		lastReportedAt:   now,
+		lastReportedDone: p.loadCounters().done,
+	}
+
+	var sampleCh <-chan time.Time
+	var sampleTicker *time.Ticker
+	if p.sampleRate > 0 {
+		state.window = &rateWindow{width: p.reportTime}
+		state.window.add(now, state.lastReportedDone)
+		sampleTicker = time.NewTicker(p.sampleRate)
+		sampleCh = sampleTicker.C
+	}
+	p.loop.Store(state)
+
+	var debounceCh <-chan time.Time
+	var debounceTimer *time.Timer
+
 	go func() {
 		defer func() {
+			if sampleTicker != nil {
+				sampleTicker.Stop()
+			}
+			if debounceTimer != nil {
+				debounceTimer.Stop()
+			}
 			p.reporter.Finalize()
 			defer close(p.doneCh)
 		}()
-		p.reporter.Report(p.Report())
+		if !p.skipFirstReport {
+			p.report()
+		}
+
+		reportTimer := time.NewTimer(p.reportTime)
+		defer reportTimer.Stop()
 		for {
 			select {
 			case <-done:
 				return
-			case <-time.After(p.reportTime):
-				p.reporter.Report(p.Report())
+			case <-p.stopCh:
+				return
+			case <-sampleCh:
+				state.window.add(p.now(), p.loadCounters().done)
+			case <-reportTimer.C:
+				p.report()
+				reportTimer.Reset(p.reportTime)
+			case <-p.changeCh:
+				if debounceTimer == nil {
+					debounceTimer = time.NewTimer(p.refreshOnAdd)
+					debounceCh = debounceTimer.C
+				} else {
+					if !debounceTimer.Stop() {
+						select {
+						case <-debounceTimer.C:
+						default:
+						}
+					}
+					debounceTimer.Reset(p.refreshOnAdd)
+				}
+			case <-debounceCh:
+				p.report()
+			case <-p.redrawCh:
+				if r, ok := p.reporter.(redrawer); ok {
+					r.redraw()
+				}
+				p.report()
 			}
 		}
 	}()
 }
 
+// OnReport registers a hook which is called with every report passed to the reporter.
+// Hooks are called from the reporter goroutine in registration order. OnReport should be
+// called before the progress tracker is started
+func (p *Progress) OnReport(fn func(Report)) {
+	p.onReport = append(p.onReport, fn)
+}
+
+// OnMilestone registers a hook which is called once for each of given percents when progress first
+// reaches it. Milestones are passed in ascending order, even if several are crossed at once. Like
+// OnReport it should be called before the progress tracker is started
+func (p *Progress) OnMilestone(percents []int, fn func(percent int)) {
+	milestones := append([]int(nil), percents...)
+	sort.Ints(milestones)
+
+	next := 0
+	p.OnReport(func(report Report) {
+		for next < len(milestones) && report.PercentFloat >= float64(milestones[next]) {
+			if next == 0 || milestones[next] != milestones[next-1] {
+				fn(milestones[next])
+			}
+			next++
+		}
+	})
+}
+
+// report builds the next report and passes it to the reporter and hooks
+func (p *Progress) report() {
+	report := p.nextReport()
+	p.reporter.Report(report)
+	for _, fn := range p.onReport {
+		fn(report)
+	}
+}
+
 // Add reports done items to the progress tracker
 func (p *Progress) Add(done int) {
+	if p.sharded != nil {
+		p.sharded.add(int64(done))
+		p.changed()
+		p.touch()
+		return
+	}
+
 	atomic.AddInt64(&p.done, int64(done))
+	p.changed()
+	p.touch()
 }
 
-// Report returns current progress report
-func (p *Progress) Report() Report {
-	if p.total == 0 {
-		return Report{}
+// AddError reports failed items. Failed items are counted as done too, so the work completes
+// even if some of the items fail
+func (p *Progress) AddError(n int) {
+	// errors never exceed done items of a report
+	atomic.AddInt64(&p.done, int64(n))
+	p.updateCounters(func(c *counters) {
+		c.errors += int64(n)
+	})
+	p.touch()
+}
+
+// AddDuration reports processed duration to the progress tracker created by NewDurationTotal
+func (p *Progress) AddDuration(d time.Duration) {
+	atomic.AddInt64(&p.done, int64(d))
+	p.changed()
+	p.touch()
+}
+
+// Set sets the number of done items
+func (p *Progress) Set(done int) {
+	n := int64(done)
+	if p.sharded != nil {
+		// shards keep their sums, the base counter compensates them
+		n -= p.sharded.sum()
+	}
+	atomic.StoreInt64(&p.done, n)
+	p.changed()
+	p.touch()
+}
+
+// touch records the moment of the last progress for the heartbeat
+func (p *Progress) touch() {
+	if p.heartbeat > 0 {
+		atomic.StoreInt64(&p.lastActivity, p.now().UnixNano())
+	}
+}
+
+// SetSecondary sets the secondary position which is drawn in the progress bar ahead of done items,
+// like the buffered part of a media player bar
+func (p *Progress) SetSecondary(n int) {
+	p.updateCounters(func(c *counters) {
+		c.secondary = int64(n)
+	})
+}
+
+// SetTotal changes total number of items. It is safe to call it concurrently
+// with Add and Report
+func (p *Progress) SetTotal(total int) {
+	if total <= 0 {
+		panic("total should be greater than 0")
+	}
+
+	p.updateCounters(func(c *counters) {
+		if p.totalFloor && int64(total) < c.total {
+			return
+		}
+		c.total = int64(total)
+	})
+}
+
+// SetQueueDepth sets the current number of items waiting to be processed. It is independent of
+// done and total and shows whether consumers keep up with producers
+func (p *Progress) SetQueueDepth(n int) {
+	atomic.StoreInt64(&p.queueDepth, int64(n))
+}
+
+// SetWorkers sets the number of workers processing items. It is used to derive per-worker rate
+func (p *Progress) SetWorkers(n int) {
+	atomic.StoreInt64(&p.workers, int64(n))
+}
+
+// Complete marks the work as complete regardless of the number of done items. It is useful when
+// total was only an estimate: reports are complete and the progress bar is full, while done and
+// total keep actual values
+func (p *Progress) Complete() {
+	p.updateCounters(func(c *counters) {
+		c.completed = true
+	})
+}
+
+// Pause marks the work as paused, e.g. while waiting for a user. Paused time is excluded from
+// ElapsedActive of reports
+func (p *Progress) Pause() {
+	now := p.now()
+	p.updateCounters(func(c *counters) {
+		if c.pausedAt.IsZero() {
+			c.pausedAt = now
+		}
+	})
+}
+
+// Resume resumes the work paused by Pause
+func (p *Progress) Resume() {
+	now := p.now()
+	p.updateCounters(func(c *counters) {
+		if c.pausedAt.IsZero() {
+			return
+		}
+		c.pausedFor += since(now, c.pausedAt)
+		c.pausedAt = time.Time{}
+	})
+}
+
+// SetPhase sets the name of the current phase of the work
+func (p *Progress) SetPhase(name string) {
+	now := p.now()
+	p.updateCounters(func(c *counters) {
+		if c.phase == name {
+			return
+		}
+		c.phase = name
+		c.phaseStartedAt = now
+		c.phaseStartDone = p.loadDone()
+	})
+}
+
+// SetMessage sets the message describing the current item, e.g. the name of the file being processed
+func (p *Progress) SetMessage(message string) {
+	p.updateCounters(func(c *counters) {
+		c.message = message
+	})
+}
+
+// updateCounters atomically replaces counters with a modified copy
+func (p *Progress) updateCounters(fn func(c *counters)) {
+	for {
+		old := p.counters.Load().(*counters)
+		c := *old
+		fn(&c)
+		if p.counters.CompareAndSwap(old, &c) {
+			break
+		}
 	}
 
-	now := time.Now()
-	dt := now.Sub(p.lastReportedAt)
+	p.changed()
+}
+
+// changed wakes up the reporter loop when it refreshes on changes, see WithRefreshOnAdd
+func (p *Progress) changed() {
+	if p.refreshOnAdd > 0 {
+		// wake up the reporter loop, a pending wake up is enough
+		select {
+		case p.changeCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// loadCounters returns counters consistent with done items: done is read while counters
+// stay the same
+func (p *Progress) loadCounters() *counters {
+	if p.source != nil {
+		return p.source()
+	}
+	for {
+		c := p.counters.Load().(*counters)
+		done := p.loadDone()
+		if p.counters.Load().(*counters) == c {
+			cc := *c
+			cc.done = done
+			return &cc
+		}
+	}
+}
+
+// loadDone returns the number of done items
+func (p *Progress) loadDone() int64 {
 	done := atomic.LoadInt64(&p.done)
-	ratio := float64(done) / float64(p.total)
-	elapsed := now.Sub(p.startedAt)
-	rps := float64(done) / now.Sub(p.startedAt).Seconds()
+	if p.sharded != nil {
+		done += p.sharded.sum()
+	}
+	return done
+}
+
+// Report returns current progress report. It has no side effects: only the reporter loop
+// advances the last report, which DT and RPSInst are measured from. Same as Snapshot
+func (p *Progress) Report() Report {
+	return p.Snapshot()
+}
+
+// nextReport returns current progress report and makes it the last one. Only the reporter loop
+// should call it
+func (p *Progress) nextReport() Report {
+	old := p.loadLoopState()
+	report := p.snapshot(old)
+
+	state := *old
+	state.lastReportedDone = report.Done64
+	state.lastReportedAt = report.Now
+	if state.reportCount > 0 || p.skipFirstReport {
+		// the initial report has no instant rate
+		p.rpsStdDev.add(report.RPSInst)
+		if p.history != nil {
+			p.history.add(report.RPSInst)
+		}
+		state.rpsStdDev = p.rpsStdDev.stdDev()
+		report.RPSStdDev = state.rpsStdDev
+	}
+	state.reportCount++
+	report.Seq = state.reportCount
+	if p.etaEstimator != nil {
+		p.etaEstimator.Update(report)
+		state.eta = p.etaEstimator.Estimate()
+		if !report.IsComplete {
+			report.ETA = state.eta
+		}
+		report.FinishAt = finishAt(report.Now, report.ETA)
+		report.WillFinish = p.willFinish(report.Now, report.ETA, report.IsComplete)
+	}
+	p.loop.Store(&state)
+
+	return report
+}
+
+// loadLoopState returns the current state of the reporter loop
+func (p *Progress) loadLoopState() *loopState {
+	return p.loop.Load().(*loopState)
+}
+
+// Snapshot returns current progress report. It has no side effects and can be called
+// at any moment without affecting the reports of the reporter loop.
+// Custom ETA estimator is not updated by Snapshot, so its last estimate is returned
+func (p *Progress) Snapshot() Report {
+	return p.snapshot(p.loadLoopState())
+}
+
+// snapshot returns current progress report derived from given state of the reporter loop
+func (p *Progress) snapshot(state *loopState) Report {
+	c := p.loadCounters()
+	done, total := c.done, c.total
+
+	// rates are measured since start or since the last phase change
+	rateSince, rateDone := state.startedAt, int64(0)
+	if p.resetRateOnPhase && c.phaseStartedAt.After(rateSince) {
+		rateSince, rateDone = c.phaseStartedAt, c.phaseStartDone
+	}
+	instSince, instDone := state.lastReportedAt, state.lastReportedDone
+	if instSince.Before(rateSince) {
+		instSince, instDone = rateSince, rateDone
+	}
+
+	now := p.now()
+	dt := since(now, state.lastReportedAt)
+	// left is never negative, even if more items than total are done
+	var left int64
+	if total > done {
+		left = total - done
+	}
+
+	var ratio float64
+	if c.completed {
+		ratio = 1
+	} else if total > 0 {
+		ratio = float64(done) / float64(total)
+	}
+	if p.totalFloor && !c.completed && ratio > TotalFloorMaxRatio {
+		ratio = TotalFloorMaxRatio
+	}
+	elapsed := since(now, state.startedAt)
+	elapsedActive := elapsed - c.pausedFor
+	if !c.pausedAt.IsZero() {
+		elapsedActive -= since(now, c.pausedAt)
+	}
+	if elapsedActive < 0 {
+		elapsedActive = 0
+	}
+	rps := perSecond(done-rateDone, since(now, rateSince))
 	var eta time.Duration
-	if rps != 0 {
-		eta = time.Duration(float64(p.total-done)/rps) * time.Second
+	if c.completed {
+		eta = 0
+	} else if p.etaEstimator != nil {
+		eta = state.eta
+	} else if p.rateLimit > 0 && total > 0 {
+		eta = time.Duration(float64(left) / p.rateLimit * float64(time.Second))
+	} else if total > 0 {
+		eta = linearETA(left, rps)
 	}
 
-	defer func() {
-		p.lastReportedDone = done
-		p.lastReportedAt = now
-	}()
+	isComplete := c.completed || !p.totalFloor && total > 0 && done >= total
+
+	workers := atomic.LoadInt64(&p.workers)
+	rpsPerWorker := rps
+	if workers > 0 {
+		rpsPerWorker = rps / float64(workers)
+	}
+
+	// the initial report is made right at start, there is no interval to measure instant rate over
+	rpsInst := perSecond(done-instDone, since(now, instSince))
+	if state.window != nil {
+		if windowRPS, ok := state.window.rate(now, done); ok {
+			rpsInst = windowRPS
+		}
+	}
+	if state.reportCount == 0 && !p.skipFirstReport {
+		dt = 0
+		rpsInst = 0
+	}
+
+	var secondaryRatio float64
+	if total > 0 {
+		secondaryRatio = float64(c.secondary) / float64(total)
+	}
+
+	var avgItem time.Duration
+	if done > 0 {
+		avgItem = elapsed / time.Duration(done)
+	}
+
+	var percentRate float64
+	if total > 0 {
+		percentRate = rpsInst / float64(total) * 100
+	}
 
 	return Report{
-		Now:          now,
-		StartedAt:    p.startedAt,
-		DT:           dt,
-		Total:        int(p.total),
-		Done:         int(done),
-		Left:         int(p.total) - int(done),
-		Ratio:        ratio,
-		PercentInt:   int(ratio * 100),
-		PercentFloat: ratio * 100,
-		Elapsed:      elapsed,
-		ETA:          eta,
-		RPSAvg:       rps,
-		RPSInst:      float64(done-p.lastReportedDone) / dt.Seconds(),
-		RPMAvg:       float64(done) / now.Sub(p.startedAt).Minutes(),
+		Now:                    now,
+		StartedAt:              state.startedAt,
+		DT:                     dt,
+		ElapsedSinceLastReport: dt,
+		Total:                  int(total),
+		Done:                   int(done),
+		Total64:                total,
+		Done64:                 done,
+		Left64:                 left,
+		Left:                   int(left),
+		Errors:                 int(c.errors),
+		Ratio:                  ratio,
+		PercentInt:             int(ratio * 100),
+		PercentFloat:           ratio * 100,
+		Elapsed:                elapsed,
+		ElapsedActive:          elapsedActive,
+		Paused:                 !c.pausedAt.IsZero(),
+		ETA:                    eta,
+		RPSAvg:                 rps,
+		RPSInst:                rpsInst,
+		RPMAvg:                 perSecond(done-rateDone, since(now, rateSince)) * 60,
+		IsComplete:             isComplete,
+		Phase:                  c.phase,
+		Message:                c.message,
+		Label:                  p.label,
+		PercentRate:            percentRate,
+		NoActivity:             p.noActivity(now, state.startedAt, isComplete),
+		AvgItemDuration:        avgItem,
+		Secondary:              c.secondary,
+		SecondaryRatio:         secondaryRatio,
+		FinishAt:               finishAt(now, eta),
+		RPSStdDev:              state.rpsStdDev,
+		QueueDepth:             int(atomic.LoadInt64(&p.queueDepth)),
+		Workers:                int(workers),
+		RPSPerWorker:           rpsPerWorker,
+		Unit:                   p.unit,
+		WillFinish:             p.willFinish(now, eta, isComplete),
 	}
 }
 
+// noActivity returns time since the last progress if it exceeds the heartbeat
+func (p *Progress) noActivity(now, startedAt time.Time, isComplete bool) time.Duration {
+	if p.heartbeat <= 0 || isComplete {
+		return 0
+	}
+
+	lastActivity := startedAt
+	if n := atomic.LoadInt64(&p.lastActivity); n != 0 {
+		lastActivity = time.Unix(0, n)
+	}
+	if inactive := since(now, lastActivity); inactive >= p.heartbeat {
+		return inactive
+	}
+	return 0
+}
+
+// willFinish returns false if the work is not going to finish by the deadline. Unknown ETA, e.g.
+// of a stalled job, is a risk too
+func (p *Progress) willFinish(now time.Time, eta time.Duration, isComplete bool) bool {
+	if p.deadline.IsZero() || isComplete {
+		return true
+	}
+	if now.After(p.deadline) || eta <= 0 {
+		return false
+	}
+	return !now.Add(eta).After(p.deadline)
+}
+
+// now returns current time from the configured clock
+func (p *Progress) now() time.Time {
+	if p.clock != nil {
+		return p.clock()
+	}
+	return time.Now()
+}
+
+// since returns time passed from t to now. It is never negative, even if the wall clock
+// went backwards and t has no monotonic reading
+func since(now, t time.Time) time.Duration {
+	d := now.Sub(t)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// perSecond returns rate of n items done in d. Zero when d is zero
+func perSecond(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
+
+// finishAt returns estimated time of finish. Zero time when ETA is unknown
+func finishAt(now time.Time, eta time.Duration) time.Time {
+	if eta <= 0 {
+		return time.Time{}
+	}
+	return now.Add(eta)
+}
+
 func (p *Progress) Done() chan struct{} {
 	return p.doneCh
 }
+
+// redrawer is implemented by reporters which can render the next report from scratch
+type redrawer interface {
+	redraw()
+}
+
+// Redraw makes the reporter loop render a report right away. Line-based reporters clear the line
+// and render it in full, which restores the output after something else was written to it
+func (p *Progress) Redraw() {
+	select {
+	case p.redrawCh <- struct{}{}:
+	default:
+	}
+}
+
+// Stop stops the progress tracker as if its context was cancelled. It is safe to call Stop
+// several times and concurrently with the controlling context
+func (p *Progress) Stop() {
+	if atomic.CompareAndSwapInt32(&p.stopped, 0, 1) {
+		close(p.stopCh)
+	}
+}
+
+// HandleSignals stops the progress tracker and waits for the last report when one of given
+// signals is received (SIGINT and SIGTERM if none given), so the terminal is left tidy on interrupt.
+// Returned function uninstalls the handler.
+//
+// Note that the first signal does not terminate the program anymore: the application is still
+// responsible for handling the signal for its own shutdown, e.g. with signal.NotifyContext.
+// The handler is uninstalled as soon as a signal is received, so the following ones are handled
+// as before HandleSignals was called
+func (p *Progress) HandleSignals(sig ...os.Signal) func() {
+	if len(sig) == 0 {
+		sig = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	quitCh := make(chan struct{})
+	signal.Notify(sigCh, sig...)
+
+	go func() {
+		select {
+		case <-sigCh:
+			signal.Stop(sigCh)
+			p.interrupt()
+		case <-quitCh:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigCh)
+			close(quitCh)
+		})
+	}
+}
+
+// interrupt handles a signal installed by HandleSignals: stops the progress tracker and waits
+// for the last report if it was started
+func (p *Progress) interrupt() {
+	p.Stop()
+	if atomic.LoadInt32(&p.started) == 1 {
+		p.Wait()
+	}
+}
+
+// Wait blocks until the last report is rendered
+func (p *Progress) Wait() {
+	<-p.doneCh
+}
+
+// WaitTimeout waits for the last report to be rendered at most d.
+// Returns true if the last report was rendered before the timeout
+func (p *Progress) WaitTimeout(d time.Duration) bool {
+	select {
+	case <-p.doneCh:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
@@ -0,0 +1,855 @@
+package gopv
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// withReportTime sets the report interval of trackers created by the test
+func withReportTime(t *testing.T, d time.Duration) {
+	old := DefaultReportTime
+	DefaultReportTime = d
+	t.Cleanup(func() {
+		DefaultReportTime = old
+	})
+}
+
+func TestConcurrentAddSetTotalSnapshot(t *testing.T) {
+	withReportTime(t, time.Millisecond)
+	pv := New(1000).WithReporter(NewNullReporter())
+	stop := make(chan struct{})
+	StartChan(pv, stop)
+
+	const workers, adds = 4, 10000
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < adds; j++ {
+				pv.Add(1)
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < adds; i++ {
+			pv.SetTotal(1000 + i%2*1000)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < adds; i++ {
+			r := pv.Snapshot()
+			if r.Total64 != 1000 && r.Total64 != 2000 {
+				t.Errorf("unexpected total %d", r.Total64)
+				return
+			}
+			if want := float64(r.Done64) / float64(r.Total64); r.Ratio != want {
+				t.Errorf("ratio %v does not match done %d and total %d", r.Ratio, r.Done64, r.Total64)
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(stop)
+	pv.Wait()
+
+	if done := pv.Snapshot().Done64; done != workers*adds {
+		t.Fatalf("done = %d, want %d", done, workers*adds)
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	pv := New(1 << 30)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		pv.Add(1)
+	}
+}
+
+func BenchmarkAddParallel(b *testing.B) {
+	pv := New(1 << 30)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			pv.Add(1)
+		}
+	})
+}
+
+// steppingClock returns a clock which advances by step on every call
+func steppingClock(step time.Duration) func() time.Time {
+	var mu sync.Mutex
+	now := time.Date(2023, 12, 2, 8, 52, 24, 0, time.UTC)
+	return func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(step)
+		return now
+	}
+}
+
+func TestSnapshotDoesNotAffectLoopDT(t *testing.T) {
+	withReportTime(t, time.Millisecond)
+
+	var mu sync.Mutex
+	var reports []Report
+	pv := New(1000).WithReporter(NewNullReporter()).WithClock(steppingClock(100 * time.Millisecond))
+	pv.OnReport(func(r Report) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, r)
+	})
+
+	stop := make(chan struct{})
+	StartChan(pv, stop)
+	for i := 0; i < 200; i++ {
+		pv.Add(1)
+		_ = pv.Snapshot()
+		time.Sleep(50 * time.Microsecond)
+	}
+	close(stop)
+	pv.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reports) < 3 {
+		t.Fatalf("got %d loop reports, want at least 3", len(reports))
+	}
+	for i := 1; i < len(reports); i++ {
+		want := reports[i].Now.Sub(reports[i-1].Now)
+		if reports[i].DT != want || reports[i].ElapsedSinceLastReport != want {
+			t.Fatalf("report %d: DT = %v, ElapsedSinceLastReport = %v, want %v",
+				i, reports[i].DT, reports[i].ElapsedSinceLastReport, want)
+		}
+	}
+}
+
+// manualClock is a clock which is advanced by tests
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newManualClock() *manualClock {
+	return &manualClock{now: time.Date(2023, 12, 2, 8, 52, 24, 0, time.UTC)}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// startManual starts progress tracker and stops it when the test finishes
+func startManual(t *testing.T, pv *Progress) {
+	stop := make(chan struct{})
+	StartChan(pv, stop)
+	t.Cleanup(func() {
+		close(stop)
+		pv.Wait()
+	})
+}
+
+func TestWillFinish(t *testing.T) {
+	tests := []struct {
+		name     string
+		deadline time.Duration
+		done     int
+		want     bool
+	}{
+		// 100 items in 10 seconds, 900 items left take 90 seconds
+		{name: "in time", deadline: 2 * time.Minute, done: 100, want: true},
+		{name: "near deadline at slow rate", deadline: 5 * time.Second, done: 100, want: false},
+		{name: "stalled", deadline: time.Hour, done: 0, want: false},
+		{name: "complete", deadline: time.Second, done: 1000, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newManualClock()
+			pv := New(1000).WithReporter(NewNullReporter()).WithClock(clock.Now).
+				WithDeadline(clock.Now().Add(10*time.Second + tt.deadline))
+			startManual(t, pv)
+
+			clock.Advance(10 * time.Second)
+			pv.Add(tt.done)
+			report := pv.Snapshot()
+			if report.WillFinish != tt.want {
+				t.Fatalf("WillFinish = %v with ETA %v, want %v", report.WillFinish, report.ETA, tt.want)
+			}
+
+			risk := NewTextReporter().WithLegend("{deadline_risk}").RenderString(report)
+			if wantRisk := map[bool]string{true: "", false: "!"}[tt.want]; risk != wantRisk {
+				t.Fatalf("{deadline_risk} = %q, want %q", risk, wantRisk)
+			}
+		})
+	}
+}
+
+func TestWillFinishWithoutDeadline(t *testing.T) {
+	pv := New(1000).WithReporter(NewNullReporter())
+	startManual(t, pv)
+	if !pv.Snapshot().WillFinish {
+		t.Fatal("WillFinish should be true without deadline")
+	}
+}
+
+func TestIsComplete(t *testing.T) {
+	pv := New(10).WithReporter(NewNullReporter())
+	startManual(t, pv)
+
+	pv.Add(9)
+	if report := pv.Snapshot(); report.IsComplete {
+		t.Fatalf("report of %d/%d items is complete", report.Done, report.Total)
+	}
+	pv.Add(1)
+	if report := pv.Snapshot(); !report.IsComplete {
+		t.Fatalf("report of %d/%d items is not complete", report.Done, report.Total)
+	}
+	pv.Add(1)
+	if report := pv.Snapshot(); !report.IsComplete {
+		t.Fatalf("report of %d/%d items is not complete", report.Done, report.Total)
+	}
+
+	if NewReport(WithDone(9), WithTotal(10)).IsComplete || !NewReport(WithDone(10), WithTotal(10)).IsComplete {
+		t.Fatal("NewReport does not complete at done == total")
+	}
+}
+
+// recordingReporter keeps all reports passed to it
+type recordingReporter struct {
+	mu        sync.Mutex
+	reports   []Report
+	finalized bool
+}
+
+func (r *recordingReporter) Report(report Report) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, report)
+}
+
+func (r *recordingReporter) Finalize() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finalized = true
+}
+
+func TestOnReport(t *testing.T) {
+	withReportTime(t, time.Millisecond)
+	reporter := &recordingReporter{}
+	pv := New(100).WithReporter(reporter)
+
+	// hooks are called from the reporter goroutine only, so no locking is needed until Wait
+	var calls []int
+	var seqs []int
+	pv.OnReport(func(report Report) {
+		calls = append(calls, 1)
+		seqs = append(seqs, report.Seq)
+	})
+	pv.OnReport(func(Report) {
+		calls = append(calls, 2)
+	})
+
+	startManual(t, pv)
+	for i := 0; i < 5; i++ {
+		pv.Add(10)
+		time.Sleep(5 * time.Millisecond)
+	}
+	pv.Stop()
+	pv.Wait()
+
+	if len(reporter.reports) < 5 {
+		t.Fatalf("only %d reports over the run", len(reporter.reports))
+	}
+	if len(calls) != 2*len(reporter.reports) {
+		t.Fatalf("hooks are called %d times for %d reports", len(calls), len(reporter.reports))
+	}
+	for i, report := range reporter.reports {
+		if calls[2*i] != 1 || calls[2*i+1] != 2 {
+			t.Fatalf("hooks are not called in registration order: %v", calls)
+		}
+		if seqs[i] != report.Seq {
+			t.Fatalf("hook %d got report %d, reporter got %d", i, seqs[i], report.Seq)
+		}
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	pv := New(10).WithReporter(NewNullReporter())
+	stop := make(chan struct{})
+	StartChan(pv, stop)
+
+	if pv.WaitTimeout(10 * time.Millisecond) {
+		t.Fatal("WaitTimeout returns true for running tracker")
+	}
+
+	close(stop)
+	if !pv.WaitTimeout(time.Second) {
+		t.Fatal("WaitTimeout returns false for stopped tracker")
+	}
+	pv.Wait()
+}
+
+func TestRateLimitETA(t *testing.T) {
+	clock := newManualClock()
+	pv := New(1000).WithReporter(NewNullReporter()).WithClock(clock.Now).WithRateLimit(50)
+	startManual(t, pv)
+
+	if got := pv.Snapshot().ETA; got != 20*time.Second {
+		t.Fatalf("ETA before any progress = %v, want 20s", got)
+	}
+
+	// observed rate is 100 items per second, but 900 items left take 18 seconds at the limit
+	clock.Advance(time.Second)
+	pv.Add(100)
+	if got := pv.Snapshot().ETA; got != 18*time.Second {
+		t.Fatalf("ETA = %v, want 18s", got)
+	}
+}
+
+func TestSetPhase(t *testing.T) {
+	tests := []struct {
+		reset   bool
+		wantRPS float64
+	}{
+		// 100 items in the first 10 seconds, 1000 items in the next 10 seconds
+		{reset: false, wantRPS: 55},
+		{reset: true, wantRPS: 100},
+	}
+	for _, tt := range tests {
+		clock := newManualClock()
+		pv := New(10000).WithReporter(NewNullReporter()).WithClock(clock.Now).WithResetRateOnPhase(tt.reset)
+		startManual(t, pv)
+		r := NewTextReporter().WithLegend("{phase}: {rps_avg}")
+
+		pv.SetPhase("download")
+		clock.Advance(10 * time.Second)
+		pv.Add(100)
+		if got, want := r.RenderString(pv.Snapshot()), "download: 10.00"; got != want {
+			t.Fatalf("reset %v: got %q, want %q", tt.reset, got, want)
+		}
+
+		pv.SetPhase("unpack")
+		clock.Advance(10 * time.Second)
+		pv.Add(1000)
+		report := pv.Snapshot()
+		if report.Phase != "unpack" || report.RPSAvg != tt.wantRPS {
+			t.Fatalf("reset %v: phase %q at %v RPS, want %q at %v RPS", tt.reset, report.Phase, report.RPSAvg, "unpack", tt.wantRPS)
+		}
+	}
+}
+
+func TestStartTwice(t *testing.T) {
+	pv := New(10).WithReporter(NewNullReporter())
+	startManual(t, pv)
+
+	defer func() {
+		if r := recover(); r != "progress already started" {
+			t.Fatalf("second start recovered %v, want panic with clear message", r)
+		}
+	}()
+	StartChan(pv, make(chan struct{}))
+}
+
+func TestClockGoingBackwards(t *testing.T) {
+	clock := newManualClock()
+	pv := New(100).WithReporter(NewNullReporter()).WithClock(clock.Now)
+	startManual(t, pv)
+
+	clock.Advance(-time.Minute)
+	pv.Add(10)
+	report := pv.Snapshot()
+	durations := map[string]time.Duration{
+		"DT":                     report.DT,
+		"ElapsedSinceLastReport": report.ElapsedSinceLastReport,
+		"Elapsed":                report.Elapsed,
+		"ElapsedActive":          report.ElapsedActive,
+		"ETA":                    report.ETA,
+		"AvgItemDuration":        report.AvgItemDuration,
+	}
+	for name, d := range durations {
+		if d < 0 {
+			t.Errorf("%s is negative: %v", name, d)
+		}
+	}
+	if report.RPSAvg < 0 || report.RPSInst < 0 {
+		t.Errorf("rates are negative: avg %v, inst %v", report.RPSAvg, report.RPSInst)
+	}
+}
+
+func TestFirstReport(t *testing.T) {
+	reporter := &recordingReporter{}
+	// every reading of the clock advances it, so the first report is made a bit after start
+	pv := New(100).WithReporter(reporter).WithClock(steppingClock(time.Millisecond))
+	pv.Add(50)
+	stop := make(chan struct{})
+	StartChan(pv, stop)
+	close(stop)
+	pv.Wait()
+
+	if len(reporter.reports) == 0 {
+		t.Fatal("no reports")
+	}
+	first := reporter.reports[0]
+	if first.Seq != 1 || first.DT != 0 || first.RPSInst != 0 {
+		t.Fatalf("first report %d: DT %v, RPSInst %v, want 0 and 0", first.Seq, first.DT, first.RPSInst)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	pv := New(100).WithReporter(NewNullReporter())
+	startManual(t, pv)
+	pv.Add(70)
+	pv.Complete()
+
+	report := pv.Snapshot()
+	if !report.IsComplete || report.Ratio != 1 || report.Done != 70 || report.Total != 100 {
+		t.Fatalf("complete %v, ratio %v, %d/%d, want complete at full ratio with 70/100",
+			report.IsComplete, report.Ratio, report.Done, report.Total)
+	}
+	r := NewTextReporter().WithLegend("{progress_bar} {done}/{total}").WithProgressBarWidth(12)
+	if got, want := r.RenderString(report), "[##########] 70/100"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSkipInitialReport(t *testing.T) {
+	// nothing is reported before the first tick, which never comes during the test
+	withReportTime(t, time.Hour)
+	for _, skip := range []bool{false, true} {
+		reporter := &recordingReporter{}
+		pv := New(100).WithReporter(reporter).WithSkipInitialReport(skip)
+		stop := make(chan struct{})
+		StartChan(pv, stop)
+		pv.Add(10)
+		close(stop)
+		pv.Wait()
+
+		want := 1
+		if skip {
+			want = 0
+		}
+		if len(reporter.reports) != want || !reporter.finalized {
+			t.Errorf("skip %v: %d reports, finalized %v, want %d reports", skip, len(reporter.reports), reporter.finalized, want)
+		}
+	}
+}
+
+func TestQueueDepth(t *testing.T) {
+	pv := New(100).WithReporter(NewNullReporter())
+	startManual(t, pv)
+	r := NewTextReporter().WithLegend("queue {queue}")
+
+	for _, depth := range []int{5, 42, 0} {
+		pv.SetQueueDepth(depth)
+		report := pv.Snapshot()
+		if report.QueueDepth != depth {
+			t.Fatalf("QueueDepth = %d, want %d", report.QueueDepth, depth)
+		}
+		if got, want := r.RenderString(report), "queue "+strconv.Itoa(depth); got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestHandleSignals(t *testing.T) {
+	var out bytes.Buffer
+	pv := New(100).WithReporter(NewTextReporter().WithOutput(&out).WithLegend("{done}/{total}\r"))
+	stop := make(chan struct{})
+	defer close(stop)
+	StartChan(pv, stop)
+	uninstall := pv.HandleSignals()
+	defer uninstall()
+
+	pv.Add(30)
+	pv.interrupt()
+
+	// the tracker is stopped and the line is finished, so the terminal is left tidy
+	select {
+	case <-pv.Done():
+	default:
+		t.Fatal("tracker is not stopped")
+	}
+	if got := out.String(); !strings.HasSuffix(got, "\n") {
+		t.Fatalf("output %q does not end with a newline", got)
+	}
+
+	// uninstalling twice is fine
+	uninstall()
+
+	// interrupt of a tracker which was never started does not block
+	New(100).WithReporter(NewNullReporter()).interrupt()
+}
+
+func TestRPSPerWorker(t *testing.T) {
+	clock := newManualClock()
+	pv := New(1000).WithReporter(NewNullReporter()).WithClock(clock.Now)
+	startManual(t, pv)
+	clock.Advance(10 * time.Second)
+	pv.Add(400)
+
+	// without workers the aggregate rate is reported
+	if report := pv.Snapshot(); report.RPSPerWorker != 40 {
+		t.Fatalf("RPSPerWorker without workers = %v, want 40", report.RPSPerWorker)
+	}
+
+	pv.SetWorkers(4)
+	report := pv.Snapshot()
+	if report.RPSPerWorker != 10 || report.Workers != 4 {
+		t.Fatalf("RPSPerWorker = %v with %d workers, want 10 with 4", report.RPSPerWorker, report.Workers)
+	}
+	if got := NewTextReporter().WithLegend("{rps_per_worker}").RenderString(report); got != "10.00" {
+		t.Fatalf("{rps_per_worker} = %q, want %q", got, "10.00")
+	}
+}
+
+func TestAddDuration(t *testing.T) {
+	pv := NewDurationTotal(2 * time.Hour).WithReporter(NewNullReporter())
+	startManual(t, pv)
+
+	for i := 0; i < 4; i++ {
+		pv.AddDuration(15 * time.Minute)
+	}
+	report := pv.Snapshot()
+	if report.PercentInt != 50 || report.Unit != UnitDuration {
+		t.Fatalf("%d%% in %q, want 50%% of duration", report.PercentInt, report.Unit)
+	}
+	if got, want := NewTextReporter().WithLegend("{done}/{total}").RenderString(report), "1h0m0s/2h0m0s"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSampleRate(t *testing.T) {
+	// the display tick never comes during the test, the sampling one comes every millisecond
+	withReportTime(t, time.Hour)
+	reporter := &recordingReporter{}
+	pv := New(1000).WithReporter(reporter).WithSampleRate(time.Millisecond)
+	stop := make(chan struct{})
+	StartChan(pv, stop)
+	for i := 0; i < 20; i++ {
+		pv.Add(1)
+		time.Sleep(time.Millisecond)
+	}
+	close(stop)
+	pv.Wait()
+
+	window := pv.loadLoopState().window
+	window.mu.Lock()
+	samples := len(window.samples)
+	window.mu.Unlock()
+	if len(reporter.reports) != 1 || samples < 5 {
+		t.Fatalf("%d samples for %d reports, want sampling more often than rendering", samples, len(reporter.reports))
+	}
+}
+
+func TestLargeTotal(t *testing.T) {
+	const total = math.MaxInt32 * 3
+	pv := NewInt64(total).WithReporter(NewNullReporter())
+	startManual(t, pv)
+	pv.Add(math.MaxInt32)
+	pv.Add(math.MaxInt32)
+
+	report := pv.Snapshot()
+	if report.Total64 != total || report.Done64 != 2*math.MaxInt32 || report.Left64 != math.MaxInt32 {
+		t.Fatalf("done %d, total %d, left %d", report.Done64, report.Total64, report.Left64)
+	}
+	if report.PercentInt != 66 {
+		t.Fatalf("%d%% done, want 66%%", report.PercentInt)
+	}
+	got := NewTextReporter().WithLegend("{done}/{total}").RenderString(report)
+	if want := "4294967294/6442450941"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRefreshOnAdd(t *testing.T) {
+	withReportTime(t, time.Hour)
+	reporter := &recordingReporter{}
+	pv := New(100).WithReporter(reporter).WithSkipInitialReport(true).WithRefreshOnAdd(20 * time.Millisecond)
+	stop := make(chan struct{})
+	StartChan(pv, stop)
+
+	// a burst of adds is coalesced into a single render after the debounce interval
+	for i := 0; i < 10; i++ {
+		pv.Add(1)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(stop)
+	pv.Wait()
+
+	if len(reporter.reports) != 1 || reporter.reports[0].Done != 10 {
+		t.Fatalf("%d reports, want a single one with all items done", len(reporter.reports))
+	}
+}
+
+func TestStartCtxLabel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), LabelKey, "req-42"))
+	var out bytes.Buffer
+	pv := New(10).WithReporter(NewJSONReporter().WithOutput(&out))
+	StartCtx(pv, ctx)
+	pv.Add(3)
+
+	report := pv.Snapshot()
+	if report.Label != "req-42" {
+		t.Fatalf("label = %q, want req-42", report.Label)
+	}
+	if got := NewTextReporter().WithLegend("[{label}] {done}/{total}").RenderString(report); got != "[req-42] 3/10" {
+		t.Fatalf("got %q", got)
+	}
+
+	cancel()
+	pv.Wait()
+	if !strings.Contains(out.String(), `"label":"req-42"`) {
+		t.Fatalf("JSON reports are not labeled: %s", out.String())
+	}
+
+	// explicit label takes precedence over the context
+	ctx, cancel = context.WithCancel(context.WithValue(context.Background(), LabelKey, "req-43"))
+	pv = New(10).WithReporter(NewNullReporter()).WithLabel("import")
+	StartCtx(pv, ctx)
+	defer pv.Wait()
+	defer cancel()
+	if got := pv.Snapshot().Label; got != "import" {
+		t.Fatalf("label = %q, want import", got)
+	}
+}
+
+func TestPercentRate(t *testing.T) {
+	clock := newManualClock()
+	pv := New(1000).WithReporter(NewNullReporter()).WithClock(clock.Now).WithSkipInitialReport(true)
+	startManual(t, pv)
+
+	pv.Add(8)
+	clock.Advance(time.Second)
+	report := pv.Snapshot()
+	if report.PercentRate != 0.8 {
+		t.Fatalf("percent rate = %v, want 0.8", report.PercentRate)
+	}
+	got := NewTextReporter().WithLegend("{percent_rate}%%/s").WithFloatPrecision(1).RenderString(report)
+	if got != "0.8%/s" {
+		t.Fatalf("got %q, want 0.8%%/s", got)
+	}
+
+	// percent rate is unknown without total
+	pv = NewIndeterminate().WithReporter(NewNullReporter()).WithClock(clock.Now).WithSkipInitialReport(true)
+	startManual(t, pv)
+	pv.Add(8)
+	clock.Advance(time.Second)
+	if report := pv.Snapshot(); report.RPSInst != 8 || report.PercentRate != 0 {
+		t.Fatalf("RPS = %v, percent rate = %v, want 8 and 0", report.RPSInst, report.PercentRate)
+	}
+}
+
+func TestRedraw(t *testing.T) {
+	withReportTime(t, time.Hour)
+	var out bytes.Buffer
+	pv := New(10).WithReporter(NewTextReporter().WithOutput(&out).WithForceTTY(true).WithLegend("{done}/{total}\r"))
+	reported := make(chan Report, 2)
+	pv.OnReport(func(report Report) {
+		reported <- report
+	})
+	stop := make(chan struct{})
+	StartChan(pv, stop)
+	<-reported
+
+	// the redraw is rendered right away, not at the next report interval
+	pv.Add(5)
+	pv.Redraw()
+	<-reported
+	close(stop)
+	pv.Wait()
+
+	// the line is wiped out and rendered in full
+	if want := "0/10\r\r    \r5/10\r"; !strings.HasPrefix(out.String(), want) {
+		t.Fatalf("got %q, want it to start with %q", out.String(), want)
+	}
+}
+
+func TestOnMilestone(t *testing.T) {
+	withReportTime(t, time.Hour)
+	pv := New(100).WithReporter(NewNullReporter())
+	// hooks are called from the reporter goroutine only, so no locking is needed until Wait
+	var fired []int
+	pv.OnMilestone([]int{75, 25, 50, 50, 90}, func(percent int) {
+		fired = append(fired, percent)
+	})
+	reported := make(chan struct{}, 1)
+	pv.OnReport(func(Report) {
+		select {
+		case reported <- struct{}{}:
+		default:
+		}
+	})
+	stop := make(chan struct{})
+	StartChan(pv, stop)
+	<-reported
+
+	// a single step crosses three milestones
+	pv.Add(80)
+	pv.Redraw()
+	<-reported
+	// milestones already fired are not repeated
+	pv.Redraw()
+	<-reported
+	pv.Add(20)
+	pv.Redraw()
+	<-reported
+	close(stop)
+	pv.Wait()
+
+	if want := []int{25, 50, 75, 90}; !reflect.DeepEqual(fired, want) {
+		t.Fatalf("fired %v, want %v", fired, want)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	clock := newManualClock()
+	pv := New(100).WithReporter(NewNullReporter()).WithClock(clock.Now).WithHeartbeat(30 * time.Second)
+	startManual(t, pv)
+
+	pv.Add(10)
+	clock.Advance(20 * time.Second)
+	if got := pv.Snapshot().NoActivity; got != 0 {
+		t.Fatalf("no activity for %v before the heartbeat", got)
+	}
+
+	clock.Advance(25 * time.Second)
+	report := pv.Snapshot()
+	if report.NoActivity != 45*time.Second {
+		t.Fatalf("no activity for %v, want 45s", report.NoActivity)
+	}
+	got := NewTextReporter().WithLegend("{done}/{total}\r").RenderString(report)
+	if want := "10/100 (no activity for 45s)"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// any progress resets the heartbeat
+	pv.Set(11)
+	clock.Advance(time.Second)
+	if got := pv.Snapshot().NoActivity; got != 0 {
+		t.Fatalf("no activity for %v right after Set", got)
+	}
+}
+
+func TestAvgItemDuration(t *testing.T) {
+	clock := newManualClock()
+	pv := New(100).WithReporter(NewNullReporter()).WithClock(clock.Now)
+	startManual(t, pv)
+	if got := pv.Snapshot().AvgItemDuration; got != 0 {
+		t.Fatalf("average item duration %v before any item is done", got)
+	}
+
+	pv.Add(10)
+	clock.Advance(13 * time.Second)
+	report := pv.Snapshot()
+	if report.AvgItemDuration != 1300*time.Millisecond {
+		t.Fatalf("average item duration %v, want 1.3s", report.AvgItemDuration)
+	}
+	if got := NewTextReporter().WithLegend("~{avg_item}/item").RenderString(report); got != "~1.3s/item" {
+		t.Fatalf("got %q, want ~1.3s/item", got)
+	}
+}
+
+func TestTotalFloor(t *testing.T) {
+	pv := New(100).WithReporter(NewNullReporter()).WithTotalFloor(true)
+	startManual(t, pv)
+
+	// done items catching up with the floor do not complete the job
+	pv.Add(100)
+	report := pv.Snapshot()
+	if report.PercentInt != 99 || report.IsComplete {
+		t.Fatalf("%d%% done, complete %v, want 99%% and not complete", report.PercentInt, report.IsComplete)
+	}
+
+	// the floor is raised by discovered items and is never lowered
+	pv.SetTotal(200)
+	pv.SetTotal(150)
+	if report := pv.Snapshot(); report.Total != 200 || report.PercentInt != 50 {
+		t.Fatalf("total %d, %d%% done, want 200 and 50%%", report.Total, report.PercentInt)
+	}
+	pv.Add(150)
+	if report := pv.Snapshot(); report.PercentInt != 99 || report.IsComplete {
+		t.Fatalf("%d%% done, complete %v, want 99%% and not complete", report.PercentInt, report.IsComplete)
+	}
+
+	pv.Complete()
+	if report := pv.Snapshot(); report.PercentInt != 100 || !report.IsComplete {
+		t.Fatalf("%d%% done, complete %v after Complete", report.PercentInt, report.IsComplete)
+	}
+}
+
+func TestElapsedActive(t *testing.T) {
+	clock := newManualClock()
+	pv := New(100).WithReporter(NewNullReporter()).WithClock(clock.Now)
+	startManual(t, pv)
+
+	clock.Advance(10 * time.Second)
+	pv.Pause()
+	clock.Advance(5 * time.Second)
+	// time paused so far is excluded even before Resume
+	if report := pv.Snapshot(); report.Elapsed != 15*time.Second || report.ElapsedActive != 10*time.Second {
+		t.Fatalf("elapsed %v, active %v, want 15s and 10s", report.Elapsed, report.ElapsedActive)
+	}
+
+	clock.Advance(25 * time.Second)
+	pv.Resume()
+	clock.Advance(20 * time.Second)
+	report := pv.Snapshot()
+	if report.Elapsed != time.Minute || report.ElapsedActive != 30*time.Second {
+		t.Fatalf("elapsed %v, active %v, want 1m0s and 30s", report.Elapsed, report.ElapsedActive)
+	}
+	got := NewTextReporter().WithLegend("{elapsed_active} of {elapsed}").RenderString(report)
+	if want := "30s of 1m0s"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestReportSeq(t *testing.T) {
+	withReportTime(t, time.Millisecond)
+	reporter := &recordingReporter{}
+	pv := New(100).WithReporter(reporter)
+	startManual(t, pv)
+	for i := 0; i < 5; i++ {
+		pv.Add(10)
+		// snapshots are not reports of the loop and do not take sequence numbers
+		pv.Snapshot()
+		time.Sleep(5 * time.Millisecond)
+	}
+	pv.Stop()
+	pv.Wait()
+
+	if len(reporter.reports) < 5 {
+		t.Fatalf("only %d reports over the run", len(reporter.reports))
+	}
+	for i, report := range reporter.reports {
+		if report.Seq != i+1 {
+			t.Fatalf("report %d has sequence number %d", i, report.Seq)
+		}
+	}
+
+	last := reporter.reports[len(reporter.reports)-1]
+	got := NewTextReporter().WithLegend("#{seq}").RenderString(last)
+	if want := "#" + strconv.Itoa(len(reporter.reports)); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+// Package imagereporter provides a gopv reporter which renders the final progress as a PNG image.
+// It lives in a separate package so programs which do not need images do not link image encoders.
+package imagereporter
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"github.com/pavel-krush/gopv"
+)
+
+const (
+	// DefaultWidth is the default image width in pixels
+	DefaultWidth = 400
+	// DefaultHeight is the default image height in pixels
+	DefaultHeight = 24
+)
+
+var (
+	// DefaultFillColor is the default color of the done part of the bar
+	DefaultFillColor = color.RGBA{R: 0x2e, G: 0xa0, B: 0x43, A: 0xff}
+	// DefaultEmptyColor is the default color of the remaining part of the bar
+	DefaultEmptyColor = color.RGBA{R: 0xe1, G: 0xe4, B: 0xe8, A: 0xff}
+	// DefaultBorderColor is the default color of the bar border
+	DefaultBorderColor = color.RGBA{R: 0x58, G: 0x60, B: 0x69, A: 0xff}
+)
+
+// ImageReporter renders the last received report as a progress bar image and writes it
+// to the output as PNG on Finalize.
+type ImageReporter struct {
+	// config - should be copied in clone()
+	output      io.Writer
+	width       int
+	height      int
+	fillColor   color.Color
+	emptyColor  color.Color
+	borderColor color.Color
+
+	// runtime vars. should not be copied in clone()
+	lastReport gopv.Report
+	err        error
+}
+
+// NewImageReporter returns a new instance of reporter writing PNG image to given output
+func NewImageReporter(output io.Writer) *ImageReporter {
+	return &ImageReporter{
+		output:      output,
+		width:       DefaultWidth,
+		height:      DefaultHeight,
+		fillColor:   DefaultFillColor,
+		emptyColor:  DefaultEmptyColor,
+		borderColor: DefaultBorderColor,
+	}
+}
+
+// WithSize returns a new instance of ImageReporter with custom image size in pixels
+func (r *ImageReporter) WithSize(width, height int) *ImageReporter {
+	ret := r.clone()
+	ret.width = width
+	ret.height = height
+	return ret
+}
+
+// WithColors returns a new instance of ImageReporter with custom colors. nil fill or empty keeps
+// the previous color, nil border means no border
+func (r *ImageReporter) WithColors(fill, empty, border color.Color) *ImageReporter {
+	ret := r.clone()
+	if fill != nil {
+		ret.fillColor = fill
+	}
+	if empty != nil {
+		ret.emptyColor = empty
+	}
+	ret.borderColor = border
+	return ret
+}
+
+// Report remembers the report to be rendered on Finalize
+func (r *ImageReporter) Report(report gopv.Report) {
+	r.lastReport = report
+}
+
+// Finalize renders the last report and writes it to the output. Encoding error can be checked with Err()
+func (r *ImageReporter) Finalize() {
+	r.err = png.Encode(r.output, r.Render(r.lastReport))
+}
+
+// Err returns error occurred while writing image on Finalize
+func (r *ImageReporter) Err() error {
+	return r.err
+}
+
+// Render draws given report as a progress bar image
+func (r *ImageReporter) Render(report gopv.Report) image.Image {
+	bounds := image.Rect(0, 0, r.width, r.height)
+	img := image.NewRGBA(bounds)
+	draw.Draw(img, bounds, image.NewUniform(r.emptyColor), image.Point{}, draw.Src)
+
+	bar := bounds
+	if r.borderColor != nil && r.width > 2 && r.height > 2 {
+		draw.Draw(img, bounds, image.NewUniform(r.borderColor), image.Point{}, draw.Src)
+		bar = bounds.Inset(1)
+		draw.Draw(img, bar, image.NewUniform(r.emptyColor), image.Point{}, draw.Src)
+	}
+
+	ratio := report.Ratio
+	if ratio < 0 {
+		ratio = 0
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+
+	fill := bar
+	fill.Max.X = bar.Min.X + int(ratio*float64(bar.Dx()))
+	draw.Draw(img, fill, image.NewUniform(r.fillColor), image.Point{}, draw.Src)
+
+	return img
+}
+
+func (r *ImageReporter) clone() *ImageReporter {
+	cp := *r
+	return &cp
+}
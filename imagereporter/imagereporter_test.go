@@ -0,0 +1,64 @@
+package imagereporter
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/pavel-krush/gopv"
+)
+
+func TestFinalizeWritesPNG(t *testing.T) {
+	var out bytes.Buffer
+	r := NewImageReporter(&out).WithSize(200, 20)
+	r.Report(gopv.NewReport(gopv.WithDone(50), gopv.WithTotal(100)))
+	r.Finalize()
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 20 {
+		t.Fatalf("image size = %dx%d, want 200x20", b.Dx(), b.Dy())
+	}
+}
+
+func TestRenderFill(t *testing.T) {
+	r := NewImageReporter(nil).WithSize(102, 10)
+	img := r.Render(gopv.NewReport(gopv.WithDone(50), gopv.WithTotal(100)))
+
+	rgba := func(x, y int) color.RGBA {
+		return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+	}
+	if got := rgba(0, 0); got != DefaultBorderColor {
+		t.Errorf("border = %v, want %v", got, DefaultBorderColor)
+	}
+	if got := rgba(25, 5); got != DefaultFillColor {
+		t.Errorf("done part = %v, want %v", got, DefaultFillColor)
+	}
+	if got := rgba(75, 5); got != DefaultEmptyColor {
+		t.Errorf("remaining part = %v, want %v", got, DefaultEmptyColor)
+	}
+}
+
+func TestWithColorsNil(t *testing.T) {
+	border := color.RGBA{A: 0xff}
+	r := NewImageReporter(nil).WithSize(102, 10).WithColors(nil, nil, border)
+	img := r.Render(gopv.NewReport(gopv.WithDone(50), gopv.WithTotal(100)))
+
+	if got := color.RGBAModel.Convert(img.At(25, 5)).(color.RGBA); got != DefaultFillColor {
+		t.Errorf("done part = %v, want previous fill %v", got, DefaultFillColor)
+	}
+	if got := color.RGBAModel.Convert(img.At(0, 0)).(color.RGBA); got != border {
+		t.Errorf("border = %v, want %v", got, border)
+	}
+
+	img = r.WithColors(nil, nil, nil).Render(gopv.NewReport(gopv.WithDone(50), gopv.WithTotal(100)))
+	if got := color.RGBAModel.Convert(img.At(0, 5)).(color.RGBA); got != DefaultFillColor {
+		t.Errorf("without border the bar starts at the edge, got %v", got)
+	}
+}
@@ -0,0 +1,85 @@
+package gopv
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// JSONReporter writes every report as a single JSON object per line (NDJSON).
+// Recorded reports can be rendered again with ReplayJSON.
+type JSONReporter struct {
+	// config - should be copied in clone()
+	output io.Writer
+
+	// runtime vars. should not be copied in clone()
+	encoder *json.Encoder
+}
+
+// NewJSONReporter returns a new instance of reporter writing to stderr
+func NewJSONReporter() *JSONReporter {
+	return &JSONReporter{
+		output: os.Stderr,
+	}
+}
+
+// WithOutput returns a new instance of JSONReporter with custom output
+func (r *JSONReporter) WithOutput(output io.Writer) *JSONReporter {
+	ret := r.clone()
+	ret.output = output
+	return ret
+}
+
+// Report writes report as a JSON line. Write errors are discarded
+func (r *JSONReporter) Report(report Report) {
+	if r.encoder == nil {
+		r.encoder = json.NewEncoder(r.output)
+	}
+	_ = r.encoder.Encode(report)
+}
+
+// Finalize does nothing, every report is written immediately
+func (r *JSONReporter) Finalize() {}
+
+func (r *JSONReporter) clone() *JSONReporter {
+	cp := *r
+	cp.encoder = nil
+	return &cp
+}
+
+// ReplayJSON reads reports written by JSONReporter and passes them to given reporter
+// as fast as possible. The reporter is finalized when input ends
+func ReplayJSON(r io.Reader, reporter Reporter) error {
+	return replayJSON(r, reporter, false)
+}
+
+// ReplayJSONRealTime is like ReplayJSON but keeps original intervals between reports
+// according to their Now timestamps
+func ReplayJSONRealTime(r io.Reader, reporter Reporter) error {
+	return replayJSON(r, reporter, true)
+}
+
+func replayJSON(r io.Reader, reporter Reporter, realTime bool) error {
+	defer reporter.Finalize()
+
+	decoder := json.NewDecoder(r)
+	var prevNow time.Time
+	for {
+		var report Report
+		if err := decoder.Decode(&report); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if realTime && !prevNow.IsZero() && report.Now.After(prevNow) {
+			time.Sleep(report.Now.Sub(prevNow))
+		}
+		prevNow = report.Now
+
+		reporter.Report(report)
+	}
+}
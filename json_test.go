@@ -0,0 +1,72 @@
+package gopv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayJSON(t *testing.T) {
+	now := time.Date(2023, 12, 2, 8, 52, 24, 0, time.UTC)
+	reports := []Report{
+		NewReport(WithNow(now), WithDone(0), WithTotal(120)),
+		NewReport(WithNow(now.Add(5*time.Second)), WithDone(30), WithTotal(120), WithElapsed(5*time.Second)),
+		NewReport(WithNow(now.Add(10*time.Second)), WithDone(120), WithTotal(120), WithElapsed(10*time.Second)),
+	}
+
+	var recorded bytes.Buffer
+	jr := NewJSONReporter().WithOutput(&recorded)
+	var want bytes.Buffer
+	tr := NewTextReporter().WithOutput(&want).WithLegend("[{now}] {done}/{total} {percent_int}%% ETA {eta}\n")
+	for _, report := range reports {
+		jr.Report(report)
+		tr.Report(report)
+	}
+	jr.Finalize()
+	tr.Finalize()
+
+	var got bytes.Buffer
+	err := ReplayJSON(&recorded, tr.WithOutput(&got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Fatalf("replayed output:\n%s\nwant:\n%s", got.String(), want.String())
+	}
+}
+
+func TestReplayJSONMalformed(t *testing.T) {
+	reporter := &recordingReporter{}
+	err := ReplayJSON(strings.NewReader(`{"done": 1}`+"\n"+`{"done": `), reporter)
+	if err == nil {
+		t.Fatal("no error for malformed input")
+	}
+	if len(reporter.reports) != 1 || reporter.reports[0].Done != 1 || !reporter.finalized {
+		t.Fatalf("reports before the malformed one are not replayed: %+v", reporter.reports)
+	}
+}
+
+func TestJSONReporterUnit(t *testing.T) {
+	tests := []struct {
+		pv   *Progress
+		want string
+	}{
+		{pv: New(10), want: `"unit":"items"`},
+		{pv: New(10).WithUnit(UnitBytes), want: `"unit":"bytes"`},
+		{pv: New(10).WithUnit("frames"), want: `"unit":"frames"`},
+		{pv: NewDurationTotal(time.Hour), want: `"unit":"duration"`},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		pv := tt.pv.WithReporter(NewJSONReporter().WithOutput(&out))
+		stop := make(chan struct{})
+		StartChan(pv, stop)
+		close(stop)
+		pv.Wait()
+
+		if !strings.Contains(out.String(), tt.want) {
+			t.Errorf("output %q does not contain %s", out.String(), tt.want)
+		}
+	}
+}
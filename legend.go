@@ -0,0 +1,246 @@
+package gopv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// legendSegment is either a literal part of the compiled legend or a reference to an argument
+type legendSegment struct {
+	literal string
+	// arg is the index of the argument, -1 for literals
+	arg       int
+	verb      byte
+	precision int
+	// width is the minimal number of characters, values are padded with spaces on the left
+	// or on the right if left is set
+	width int
+	left  bool
+	// spec is the format specifier of the argument without index, e.g. "%.2f"
+	spec string
+}
+
+// compiledLegend is the compiled legend split into segments, so it can be rendered without
+// parsing the format on every report
+type compiledLegend struct {
+	format   string
+	segments []legendSegment
+	used     map[int]bool
+	// ok is false when the format uses specifiers which are not produced by compileLegend.
+	// Such legends are rendered with fmt.Sprintf
+	ok bool
+}
+
+// uses reports whether the legend may use the argument with given index
+func (c *compiledLegend) uses(arg int) bool {
+	return !c.ok || c.used[arg]
+}
+
+// compileSegments splits format produced by compileLegend into segments
+func compileSegments(format string) *compiledLegend {
+	c := &compiledLegend{format: format, used: map[int]bool{}}
+
+	literal := make([]byte, 0, len(format))
+	flush := func() {
+		if len(literal) > 0 {
+			c.segments = append(c.segments, legendSegment{literal: string(literal), arg: -1})
+			literal = literal[:0]
+		}
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal = append(literal, format[i])
+			continue
+		}
+		if i+1 < len(format) && format[i+1] == '%' {
+			literal = append(literal, '%')
+			i++
+			continue
+		}
+
+		seg, n, ok := parseSpecifier(format[i:])
+		if !ok {
+			return c
+		}
+		flush()
+		c.segments = append(c.segments, seg)
+		c.used[seg.arg] = true
+		i += n - 1
+	}
+	flush()
+
+	c.ok = true
+	return c
+}
+
+// parseSpecifier parses specifier like "%[1]s", "%.2[7]f" or "%-5[8]d" at the beginning of s and
+// returns its segment and length
+func parseSpecifier(s string) (seg legendSegment, n int, ok bool) {
+	i := 1
+	left := false
+	if i < len(s) && s[i] == '-' {
+		left = true
+		i++
+	}
+
+	width := 0
+	start := i
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i > start {
+		w, err := strconv.Atoi(s[start:i])
+		if err != nil {
+			return seg, 0, false
+		}
+		width = w
+	}
+
+	precision := -1
+	if i < len(s) && s[i] == '.' {
+		i++
+		start := i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		p, err := strconv.Atoi(s[start:i])
+		if err != nil {
+			return seg, 0, false
+		}
+		precision = p
+	}
+
+	if i >= len(s) || s[i] != '[' {
+		return seg, 0, false
+	}
+	i++
+	start = i
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	arg, err := strconv.Atoi(s[start:i])
+	if err != nil || arg < 1 || i >= len(s) || s[i] != ']' {
+		return seg, 0, false
+	}
+	i++
+
+	if i >= len(s) {
+		return seg, 0, false
+	}
+	verb := s[i]
+	switch verb {
+	case 's', 'd', 'v':
+		if precision >= 0 {
+			return seg, 0, false
+		}
+	case 'f':
+		if precision < 0 {
+			precision = 6
+		}
+	default:
+		return seg, 0, false
+	}
+	i++
+
+	spec := "%"
+	if left {
+		spec += "-"
+	}
+	if width > 0 {
+		spec += strconv.Itoa(width)
+	}
+	if precision >= 0 {
+		spec += "." + strconv.Itoa(precision)
+	}
+	spec += string(verb)
+
+	return legendSegment{arg: arg - 1, verb: verb, precision: precision, width: width, left: left, spec: spec}, i, true
+}
+
+// appendLegend appends the legend rendered with given arguments to buf
+func (c *compiledLegend) appendLegend(buf []byte, args []any) []byte {
+	if !c.ok {
+		return append(buf, fmt.Sprintf(c.format, args...)...)
+	}
+
+	for _, seg := range c.segments {
+		if seg.arg < 0 {
+			buf = append(buf, seg.literal...)
+			continue
+		}
+		if seg.arg >= len(args) {
+			buf = append(buf, "%!"...)
+			buf = append(buf, seg.verb)
+			buf = append(buf, "(BADINDEX)"...)
+			continue
+		}
+		buf = appendPadded(buf, seg, args[seg.arg])
+	}
+
+	return buf
+}
+
+// appendPadded appends argument formatted and padded according to the segment to buf
+func appendPadded(buf []byte, seg legendSegment, arg any) []byte {
+	if seg.width == 0 {
+		return appendArg(buf, seg, arg)
+	}
+
+	start := len(buf)
+	buf = appendArg(buf, seg, arg)
+	// fmt pads to the number of runes
+	padding := seg.width - utf8.RuneCount(buf[start:])
+	if padding <= 0 {
+		return buf
+	}
+	if seg.left {
+		return append(buf, strings.Repeat(" ", padding)...)
+	}
+	buf = append(buf, strings.Repeat(" ", padding)...)
+	copy(buf[start+padding:], buf[start:len(buf)-padding])
+	copy(buf[start:start+padding], strings.Repeat(" ", padding))
+	return buf
+}
+
+// appendArg appends argument formatted according to the segment to buf. Integers, floats and
+// strings are formatted without fmt
+func appendArg(buf []byte, seg legendSegment, arg any) []byte {
+	switch v := arg.(type) {
+	case string:
+		if seg.verb == 's' || seg.verb == 'v' {
+			return append(buf, v...)
+		}
+	case int:
+		if seg.verb == 'd' || seg.verb == 'v' {
+			return appendInt(buf, int64(v))
+		}
+	case int64:
+		if seg.verb == 'd' || seg.verb == 'v' {
+			return appendInt(buf, v)
+		}
+	case float64:
+		if seg.verb == 'f' {
+			return appendFloat(buf, v, seg.precision)
+		}
+	case time.Duration:
+		if seg.verb == 's' || seg.verb == 'v' {
+			return append(buf, v.String()...)
+		}
+	}
+
+	return append(buf, fmt.Sprintf(seg.spec, arg)...)
+}
+
+// appendInt appends decimal integer to buf, same as %d
+func appendInt(buf []byte, n int64) []byte {
+	return strconv.AppendInt(buf, n, 10)
+}
+
+// appendFloat appends float with given number of decimals to buf, same as %.Nf
+func appendFloat(buf []byte, f float64, precision int) []byte {
+	return strconv.AppendFloat(buf, f, 'f', precision, 64)
+}
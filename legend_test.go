@@ -0,0 +1,159 @@
+package gopv
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// legendReports returns reports covering start, progress, completion and extreme values
+func legendReports() []Report {
+	now := time.Date(2023, 12, 2, 8, 52, 24, 0, time.UTC)
+	start := NewReport(WithNow(now), WithTotal(360))
+
+	progress := NewReport(WithNow(now), WithDone(29), WithErrors(3), WithTotal(360), WithElapsed(3*time.Second),
+		WithPhase("download"), WithMessage("/very/long/path/to/some/file.txt"))
+	progress.RPSInst = 9.65
+	progress.RPSStdDev = 1.25
+	progress.DT = time.Second
+	progress.QueueDepth = 12
+	progress.Workers = 4
+	progress.Label = "job"
+	progress.PercentRate = 0.8
+	progress.Seq = 7
+
+	complete := NewReport(WithNow(now), WithDone(360), WithTotal(360), WithElapsed(37*time.Second))
+
+	bytes := NewReport(WithNow(now), WithDone(3<<30), WithTotal(10<<30), WithElapsed(time.Minute),
+		WithReportUnit(UnitBytes))
+
+	huge := NewReport(WithNow(now), WithDone(1<<40), WithTotal(1<<41), WithElapsed(time.Second))
+	huge.RPSInst = -1.5
+
+	return []Report{start, progress, complete, bytes, huge}
+}
+
+// legendReporters returns reporters with options affecting format specifiers of the legend
+func legendReporters() map[string]*TextReporter {
+	reporters := map[string]*TextReporter{
+		"default":   NewTextReporter(),
+		"precision": NewTextReporter().WithFloatPrecision(0).WithPlaceholderPrecision("{rps_inst}", 4),
+		"scaled":    NewTextReporter().WithRPSScaled(true),
+	}
+	right, left := NewTextReporter(), NewTextReporter()
+	for _, p := range legendPlaceholders {
+		right = right.WithFieldAlign(p.placeholder, AlignRight, 12)
+		left = left.WithFieldAlign(p.placeholder, AlignLeft, 12)
+	}
+	reporters["right"] = right
+	reporters["left"] = left
+	return reporters
+}
+
+// TestLegendParity checks that legends rendered from compiled segments are byte-for-byte the
+// same as formatted by fmt.Sprintf for every placeholder
+func TestLegendParity(t *testing.T) {
+	all := make([]string, 0, len(legendPlaceholders))
+	legends := make([]string, 0, len(legendPlaceholders)+1)
+	for _, p := range legendPlaceholders {
+		legends = append(legends, "<"+p.placeholder+">")
+		all = append(all, p.placeholder)
+	}
+	legends = append(legends, strings.Join(all, " | ")+"%%\r")
+
+	for name, r := range legendReporters() {
+		for _, legend := range legends {
+			format := r.compileLegend(legend)
+			compiled := compileSegments(format)
+			if !compiled.ok {
+				t.Fatalf("%s: legend %q is not compiled into segments", name, legend)
+			}
+
+			for i, report := range legendReports() {
+				v := r.legendValues(report)
+				args := make([]any, len(legendPlaceholders))
+				for j := range args {
+					args[j] = r.legendArg(j, v)
+				}
+
+				want := fmt.Sprintf(format, args...)
+				if got := string(compiled.appendLegend(nil, args)); got != want {
+					t.Errorf("%s: legend %q, report %d:\n got %q\nwant %q", name, legend, i, got, want)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkReport(b *testing.B) {
+	pv := New(1 << 30).WithReporter(NewNullReporter())
+	pv.loop.Store(&loopState{startedAt: time.Now().Add(-time.Minute), lastReportedAt: time.Now()})
+	pv.Add(12345)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = pv.nextReport()
+	}
+}
+
+// BenchmarkTextRender renders reports with the default legend and the progress bar legend.
+// Rendering from compiled segments into a reused buffer takes 8 and 7 allocs/op respectively,
+// while formatting the whole legend with fmt.Sprintf took 41 and 40 allocs/op
+func BenchmarkTextRender(b *testing.B) {
+	legends := map[string]string{
+		"default":      TextReporterLegendDefault,
+		"progress_bar": TextReporterLegendProgressBar,
+	}
+	for name, legend := range legends {
+		b.Run(name, func(b *testing.B) {
+			r := NewTextReporter().WithOutput(io.Discard).WithLegend(legend)
+			report := legendReports()[1]
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				r.Report(report)
+			}
+		})
+	}
+}
+
+func TestAppendInt(t *testing.T) {
+	for _, n := range []int64{0, 1, -1, 42, -42, 1234567890, -1234567890, 1<<63 - 1, -1 << 63} {
+		if got, want := string(appendInt(nil, n)), fmt.Sprintf("%d", n); got != want {
+			t.Errorf("appendInt(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestAppendFloat(t *testing.T) {
+	values := []float64{0, 1, -1, 0.5, -0.5, 0.005, 2.675, 9.999, -9.999, 1e20, -1e-20, 123456.789,
+		math.NaN(), math.Inf(1), math.Inf(-1)}
+	for _, f := range values {
+		for precision := 0; precision <= 6; precision++ {
+			got := string(appendFloat(nil, f, precision))
+			if want := fmt.Sprintf("%.*f", precision, f); got != want {
+				t.Errorf("appendFloat(%v, %d) = %q, want %q", f, precision, got, want)
+			}
+		}
+	}
+}
+
+func TestAppendPadded(t *testing.T) {
+	args := []any{0, -42, int64(1234567), 3.14159, -2.5, math.NaN(), math.Inf(1), math.Inf(-1), "text", "文件",
+		time.Duration(0), 90 * time.Second}
+	specs := []string{"%[1]v", "%8[1]v", "%-8[1]v", "%1[1]v", "%[1]d", "%6[1]d", "%-6[1]d", "%[1]s", "%10[1]s",
+		"%-10[1]s", "%.2[1]f", "%9.2[1]f", "%-9.2[1]f", "%.0[1]f", "%3.0[1]f"}
+	for _, arg := range args {
+		for _, spec := range specs {
+			seg, _, ok := parseSpecifier(spec)
+			if !ok {
+				t.Fatalf("specifier %q is not parsed", spec)
+			}
+			got := string(appendPadded(nil, seg, arg))
+			if want := fmt.Sprintf(spec, arg); got != want {
+				t.Errorf("%q of %#v: got %q, want %q", spec, arg, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package gopv
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LineWriter owns a block of terminal lines and assigns a line slot to every reporter writing
+// through it. Every update redraws the whole block, moving the cursor and erasing lines with
+// ANSI escape sequences. On outputs without ANSI support every update is written as a new line.
+type LineWriter struct {
+	mu            sync.Mutex
+	out           io.Writer
+	tty           bool
+	ansi          bool
+	hideCompleted bool
+	lines         []string
+	completed     []bool
+	released      []bool
+	// first is the first slot of the current block, slots before it belong to finished blocks
+	first int
+	drawn int
+}
+
+// NewLineWriter returns a new instance of LineWriter writing to given output
+func NewLineWriter(out io.Writer) *LineWriter {
+	return &LineWriter{
+		out:  out,
+		tty:  isTerminal(out),
+		ansi: supportsANSI(out),
+	}
+}
+
+// WithHideCompleted returns a new instance of LineWriter which removes lines of completed reporters
+// from the block, so the remaining lines move up. Reporters should be attached to the returned instance
+func (lw *LineWriter) WithHideCompleted(hide bool) *LineWriter {
+	return &LineWriter{
+		out:           lw.out,
+		tty:           lw.tty,
+		ansi:          lw.ansi,
+		hideCompleted: hide,
+	}
+}
+
+// allocate reserves a new line slot at the bottom of the block
+func (lw *LineWriter) allocate() int {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	lw.lines = append(lw.lines, "")
+	lw.completed = append(lw.completed, false)
+	lw.released = append(lw.released, false)
+	return len(lw.lines) - 1
+}
+
+// set replaces content of the slot and redraws the block. complete tells whether the reporter
+// of the slot has finished its work. Slots of finished blocks are not drawn anymore
+func (lw *LineWriter) set(slot int, line string, complete bool) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	if slot < lw.first || slot >= len(lw.lines) {
+		return
+	}
+	lw.lines[slot] = line
+	lw.completed[slot] = complete
+	if !lw.ansi {
+		_, _ = io.WriteString(lw.out, line+"\n")
+		return
+	}
+
+	lw.redraw()
+}
+
+// release marks the slot as finished. When all slots are released the cursor is moved below
+// the block, so the following output does not overwrite it, and slots allocated later start
+// a new block
+func (lw *LineWriter) release(slot int) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	if slot < lw.first || slot >= len(lw.lines) {
+		return
+	}
+	lw.released[slot] = true
+	for _, released := range lw.released[lw.first:] {
+		if !released {
+			return
+		}
+	}
+
+	if lw.ansi && lw.drawn > 0 {
+		_, _ = io.WriteString(lw.out, "\n")
+	}
+	lw.first = len(lw.lines)
+	lw.drawn = 0
+}
+
+// print writes line above the block, which is drawn again below it
+func (lw *LineWriter) print(line string) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	if !lw.ansi || lw.drawn == 0 {
+		_, _ = io.WriteString(lw.out, line+"\n")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\r")
+	if lw.drawn > 1 {
+		sb.WriteString("\x1b[" + strconv.Itoa(lw.drawn-1) + "A")
+	}
+	sb.WriteString("\x1b[2K" + line + "\n")
+	_, _ = io.WriteString(lw.out, sb.String())
+
+	lw.drawn = 0
+	lw.redraw()
+}
+
+// bell rings the terminal bell. Nothing is written when the output is not a terminal
+func (lw *LineWriter) bell() {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	if lw.tty {
+		_, _ = io.WriteString(lw.out, "\a")
+	}
+}
+
+// redraw moves the cursor to the first line of the block and writes all lines. The cursor is
+// left at the end of the last line
+func (lw *LineWriter) redraw() {
+	var sb strings.Builder
+	sb.WriteString("\r")
+	if lw.drawn > 1 {
+		sb.WriteString("\x1b[" + strconv.Itoa(lw.drawn-1) + "A")
+	}
+
+	visible := 0
+	for i, line := range lw.lines[lw.first:] {
+		if lw.hideCompleted && lw.completed[lw.first+i] {
+			continue
+		}
+		if visible > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\x1b[2K")
+		sb.WriteString(line)
+		visible++
+	}
+
+	// erase lines left from the previous bigger block and return to the last visible line
+	if extra := lw.drawn - visible; extra > 0 {
+		moved := 0
+		for i := 0; i < extra; i++ {
+			if visible > 0 || i > 0 {
+				sb.WriteString("\n")
+				moved++
+			}
+			sb.WriteString("\x1b[2K")
+		}
+		if moved > 0 {
+			sb.WriteString("\x1b[" + strconv.Itoa(moved) + "A")
+		}
+	}
+	lw.drawn = visible
+
+	_, _ = io.WriteString(lw.out, sb.String())
+}
@@ -0,0 +1,155 @@
+package gopv
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLineWriterSlots(t *testing.T) {
+	var out bytes.Buffer
+	lw := NewLineWriter(&out)
+	lw.ansi = true
+	a := NewTextReporter().WithLegend("a {done}/{total}\r").WithLineWriter(lw)
+	b := NewTextReporter().WithLegend("b {done}/{total}\r").WithLineWriter(lw)
+
+	steps := []struct {
+		r    *TextReporter
+		done int64
+		want string
+	}{
+		{r: a, done: 1, want: "\r\x1b[2Ka 1/10"},
+		{r: b, done: 2, want: "\r\x1b[2Ka 1/10\n\x1b[2Kb 2/10"},
+		{r: a, done: 3, want: "\r\x1b[1A\x1b[2Ka 3/10\n\x1b[2Kb 2/10"},
+	}
+	for i, step := range steps {
+		out.Reset()
+		step.r.Report(NewReport(WithDone(step.done), WithTotal(10)))
+		if got := out.String(); got != step.want {
+			t.Fatalf("step %d: got %q, want %q", i, got, step.want)
+		}
+	}
+
+	// the cursor is moved below the block when both reporters are finalized
+	out.Reset()
+	a.Finalize()
+	if out.Len() != 0 {
+		t.Fatalf("output after the first reporter is finalized: %q", out.String())
+	}
+	b.Finalize()
+	if got := out.String(); got != "\n" {
+		t.Fatalf("output after both reporters are finalized: %q", got)
+	}
+}
+
+func TestLineWriterLazySlots(t *testing.T) {
+	var out bytes.Buffer
+	lw := NewLineWriter(&out)
+	lw.ansi = true
+
+	// reporters which never report take no lines, clones report on lines of their own
+	_ = NewTextReporter().WithLineWriter(lw)
+	a := NewTextReporter().WithLegend("a {done}/{total}\r").WithLineWriter(lw).WithLineWriter(lw)
+	b := a.WithLegend("b {done}/{total}\r")
+	a.Report(NewReport(WithDone(1), WithTotal(10)))
+	b.Report(NewReport(WithDone(2), WithTotal(10)))
+	if got, want := lw.lines, []string{"a 1/10", "b 2/10"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("lines %q, want %q", got, want)
+	}
+
+	// the block is finished once reporters which rendered are finalized
+	out.Reset()
+	a.Finalize()
+	b.Finalize()
+	if got := out.String(); got != "\n" {
+		t.Fatalf("output after reporters are finalized: %q", got)
+	}
+}
+
+func TestLineWriterReportAfterFinalize(t *testing.T) {
+	for _, ansi := range []bool{true, false} {
+		var out bytes.Buffer
+		lw := NewLineWriter(&out)
+		lw.ansi = ansi
+		a := NewTextReporter().WithLegend("a {done}/{total}\r").WithLineWriter(lw)
+		b := NewTextReporter().WithLegend("b {done}/{total}\r").WithLineWriter(lw)
+		a.Report(NewReport(WithDone(1), WithTotal(10)))
+		b.Report(NewReport(WithDone(2), WithTotal(10)))
+		a.Finalize()
+		b.Finalize()
+
+		// the finished block is kept, the reporter renders on a new one below it
+		out.Reset()
+		a.Report(NewReport(WithDone(3), WithTotal(10)))
+		a.Finalize()
+		want := "a 3/10\n"
+		if ansi {
+			want = "\r\x1b[2Ka 3/10\n"
+		}
+		if got := out.String(); got != want {
+			t.Fatalf("ansi %v: got %q, want %q", ansi, got, want)
+		}
+	}
+}
+
+func TestLineWriterCompletionMessage(t *testing.T) {
+	var out bytes.Buffer
+	lw := NewLineWriter(&out)
+	lw.tty, lw.ansi = true, true
+	a := NewTextReporter().WithLegend("a {done}/{total}\r").WithLineWriter(lw).WithBellOnComplete(true).
+		WithCompletionMessage(func(report Report) string {
+			return "a finished " + strconv.Itoa(report.Done) + " items"
+		})
+	b := NewTextReporter().WithLegend("b {done}/{total}\r").WithLineWriter(lw)
+	b.Report(NewReport(WithDone(2), WithTotal(10)))
+	a.Report(NewReport(WithDone(10), WithTotal(10)))
+
+	// the message is printed above the block, which is drawn again below it
+	out.Reset()
+	a.Finalize()
+	want := "\r\x1b[1A\x1b[2Ka finished 10 items\n" + "\r\x1b[2Kb 2/10\n\x1b[2Ka 10/10" + "\a"
+	if got := out.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestLineWriterPlain(t *testing.T) {
+	var out bytes.Buffer
+	lw := NewLineWriter(&out)
+	a := NewTextReporter().WithLegend("a {done}/{total}\r").WithLineWriter(lw)
+	b := NewTextReporter().WithLegend("b {done}/{total}\r").WithLineWriter(lw)
+	a.Report(NewReport(WithDone(1), WithTotal(10)))
+	b.Report(NewReport(WithDone(2), WithTotal(10)))
+	a.Finalize()
+	b.Finalize()
+
+	if got, want := out.String(), "a 1/10\nb 2/10\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestLineWriterHideCompleted(t *testing.T) {
+	var out bytes.Buffer
+	lw := NewLineWriter(&out).WithHideCompleted(true)
+	lw.ansi = true
+	reporters := make([]*TextReporter, 3)
+	for i := range reporters {
+		reporters[i] = NewTextReporter().WithLegend(string(rune('a'+i)) + " {done}/{total}\r").WithLineWriter(lw)
+		reporters[i].Report(NewReport(WithDone(1), WithTotal(10)))
+	}
+
+	// the completed second line is erased and the third one moves up
+	out.Reset()
+	reporters[1].Report(NewReport(WithDone(10), WithTotal(10)))
+	if got, want := out.String(), "\r\x1b[2A\x1b[2Ka 1/10\n\x1b[2Kc 1/10\n\x1b[2K\x1b[1A"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	out.Reset()
+	reporters[0].Report(NewReport(WithDone(2), WithTotal(10)))
+	if got := out.String(); strings.Contains(got, "b ") {
+		t.Fatalf("completed line is rendered again: %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+package gopv
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// LogLevel is a prefix of log lines written by LogReporter
+type LogLevel string
+
+const (
+	LogLevelInfo  LogLevel = "INFO"
+	LogLevelWarn  LogLevel = "WARN"
+	LogLevelError LogLevel = "ERROR"
+)
+
+const (
+	// LogReporterDefaultInterval is the default interval between log lines of LogReporter
+	LogReporterDefaultInterval = time.Minute
+)
+
+// LogReporter writes progress to a logger at its own interval regardless of how often
+// reports arrive. When no items are done for a while it logs a stall warning.
+type LogReporter struct {
+	// config - should be copied in clone()
+	logger     *log.Logger
+	interval   time.Duration
+	stallAfter time.Duration
+	stallLevel LogLevel
+
+	// runtime vars. should not be copied in clone()
+	lastReport     Report
+	lastLoggedAt   time.Time
+	lastDone       int64
+	lastProgressAt time.Time
+	stallWarned    bool
+}
+
+// NewLogReporter returns a new instance of reporter writing to given logger.
+// nil logger means standard logger writing to stderr
+func NewLogReporter(logger *log.Logger) *LogReporter {
+	if logger == nil {
+		logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+
+	return &LogReporter{
+		logger:     logger,
+		interval:   LogReporterDefaultInterval,
+		stallLevel: LogLevelWarn,
+	}
+}
+
+// WithInterval returns a new instance of LogReporter logging progress every d
+func (r *LogReporter) WithInterval(d time.Duration) *LogReporter {
+	ret := r.clone()
+	ret.interval = d
+	return ret
+}
+
+// WithStallWarn returns a new instance of LogReporter which logs at given level once no items
+// have been done for d. Zero d disables stall detection
+func (r *LogReporter) WithStallWarn(d time.Duration, level LogLevel) *LogReporter {
+	ret := r.clone()
+	ret.stallAfter = d
+	ret.stallLevel = level
+	return ret
+}
+
+// Report logs the report if the interval has passed since the last log line, and warns on stall
+func (r *LogReporter) Report(report Report) {
+	r.lastReport = report
+
+	if r.lastProgressAt.IsZero() || report.Done64 != r.lastDone {
+		r.lastDone = report.Done64
+		r.lastProgressAt = report.Now
+		r.stallWarned = false
+	}
+
+	if r.stallAfter > 0 && !r.stallWarned && !report.IsComplete {
+		if stalled := report.Now.Sub(r.lastProgressAt); stalled >= r.stallAfter {
+			r.log(r.stallLevel, fmt.Sprintf("no progress for %s, %s", stalled.Round(time.Second), r.format(report)))
+			r.stallWarned = true
+		}
+	}
+
+	if r.lastLoggedAt.IsZero() || report.Now.Sub(r.lastLoggedAt) >= r.interval {
+		r.log(LogLevelInfo, r.format(report))
+		r.lastLoggedAt = report.Now
+	}
+}
+
+// Finalize logs the last report
+func (r *LogReporter) Finalize() {
+	if r.lastLoggedAt.IsZero() {
+		// nothing was reported
+		return
+	}
+
+	if !r.lastReport.Now.Equal(r.lastLoggedAt) {
+		r.log(LogLevelInfo, r.format(r.lastReport))
+	}
+}
+
+// format renders report as a log message
+func (r *LogReporter) format(report Report) string {
+	return fmt.Sprintf("progress %d/%d (%d%%), %.2f RPS, elapsed %s, ETA %s",
+		report.Done64,
+		report.Total64,
+		report.PercentInt,
+		report.RPSAvg,
+		report.Elapsed.Round(time.Second),
+		report.ETA.Round(time.Second),
+	)
+}
+
+func (r *LogReporter) log(level LogLevel, message string) {
+	r.logger.Printf("%s: %s", level, message)
+}
+
+func (r *LogReporter) clone() *LogReporter {
+	cp := *r
+	return &cp
+}
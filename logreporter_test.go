@@ -0,0 +1,50 @@
+package gopv
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogReporter(t *testing.T) {
+	var out bytes.Buffer
+	r := NewLogReporter(log.New(&out, "", 0)).WithInterval(time.Minute).WithStallWarn(5*time.Minute, LogLevelWarn)
+
+	// reports arrive every second for 10 minutes, no items are done after the third minute
+	start := time.Date(2023, 12, 2, 8, 52, 24, 0, time.UTC)
+	for i := 0; i < 600; i++ {
+		done := int64(i)
+		if done > 180 {
+			done = 180
+		}
+		elapsed := time.Duration(i) * time.Second
+		r.Report(NewReport(WithNow(start.Add(elapsed)), WithDone(done), WithTotal(1000), WithElapsed(elapsed)))
+	}
+	r.Finalize()
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	var info, warn []string
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "INFO: "):
+			info = append(info, line)
+		case strings.HasPrefix(line, "WARN: "):
+			warn = append(warn, line)
+		default:
+			t.Fatalf("unexpected line %q", line)
+		}
+	}
+
+	// one line a minute and the last report on finalize
+	if len(info) != 11 {
+		t.Errorf("%d INFO lines, want 11:\n%s", len(info), out.String())
+	}
+	if len(warn) != 1 || !strings.HasPrefix(warn[0], "WARN: no progress for 5m0s, progress 180/1000") {
+		t.Errorf("stall warnings %q, want one after 5 minutes", warn)
+	}
+	if want := "INFO: progress 180/1000 (18%), 0.30 RPS, elapsed 9m59s, ETA 45m29s"; info[len(info)-1] != want {
+		t.Errorf("last line %q, want %q", info[len(info)-1], want)
+	}
+}
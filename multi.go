@@ -0,0 +1,101 @@
+package gopv
+
+import "sync"
+
+// MultiProgress aggregates several progress trackers into a single one. Done and total items
+// of all children are summed, rates and ETA are derived from the sums. Children are not started,
+// they are only used for counting; the MultiProgress itself is started by StartCtx or StartChan
+type MultiProgress struct {
+	aggregate *Progress
+	group     *progressGroup
+}
+
+// progressGroup is a set of children shared between copies of MultiProgress
+type progressGroup struct {
+	mu       sync.Mutex
+	children []*Progress
+	weights  []float64
+}
+
+// NewMulti creates new aggregating progress tracker without children
+func NewMulti() *MultiProgress {
+	mp := &MultiProgress{
+		aggregate: New(1),
+		group:     &progressGroup{},
+	}
+	mp.aggregate.source = mp.group.sum
+
+	return mp
+}
+
+// WithReporter returns a new instance of multi progress tracker with custom reporter
+func (mp *MultiProgress) WithReporter(r Reporter) *MultiProgress {
+	cp := *mp
+	cp.aggregate = mp.aggregate.WithReporter(r)
+	return &cp
+}
+
+// NewChild creates new child progress tracker and registers it
+func (mp *MultiProgress) NewChild(total int) *Progress {
+	child := New(total)
+	mp.Register(child)
+	return child
+}
+
+// Register adds child progress tracker. It is safe to register children while running
+func (mp *MultiProgress) Register(child *Progress) {
+	mp.group.add(child, 1)
+}
+
+// Report returns current aggregated progress report
+func (mp *MultiProgress) Report() Report {
+	return mp.aggregate.Report()
+}
+
+// Snapshot returns current aggregated progress report without side effects
+func (mp *MultiProgress) Snapshot() Report {
+	return mp.aggregate.Snapshot()
+}
+
+// Done returns a channel which is closed when the last report is rendered
+func (mp *MultiProgress) Done() chan struct{} {
+	return mp.aggregate.Done()
+}
+
+// Wait blocks until the last report is rendered
+func (mp *MultiProgress) Wait() {
+	mp.aggregate.Wait()
+}
+
+func (mp *MultiProgress) progress() *Progress {
+	return mp.aggregate
+}
+
+// add adds child with given weight
+func (g *progressGroup) add(child *Progress, weight float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.children = append(g.children, child)
+	g.weights = append(g.weights, weight)
+}
+
+// sum returns counters summed over all children. Children completed with fewer done items than
+// their total count as fully done
+func (g *progressGroup) sum() *counters {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var c counters
+	for _, child := range g.children {
+		cc := child.loadCounters()
+		done := cc.done
+		if cc.completed && done < cc.total {
+			done = cc.total
+		}
+		c.done += done
+		c.total += cc.total
+		c.errors += cc.errors
+	}
+
+	return &c
+}
@@ -0,0 +1,57 @@
+package gopv
+
+import "testing"
+
+func TestMultiProgress(t *testing.T) {
+	mp := NewMulti().WithReporter(NewNullReporter())
+	a, b := mp.NewChild(100), mp.NewChild(200)
+	stop := make(chan struct{})
+	StartChan(mp, stop)
+	defer func() {
+		close(stop)
+		mp.Wait()
+	}()
+
+	// children may be added while running
+	c := mp.NewChild(300)
+	a.Add(10)
+	b.Add(20)
+	c.Add(29)
+	c.AddError(1)
+
+	report := mp.Snapshot()
+	if report.Done != 60 || report.Total != 600 || report.Left != 540 || report.Errors != 1 {
+		t.Fatalf("done %d, total %d, left %d, errors %d, want 60, 600, 540 and 1",
+			report.Done, report.Total, report.Left, report.Errors)
+	}
+	if report.Ratio != 0.1 || report.IsComplete {
+		t.Fatalf("ratio %v, complete %v, want 0.1 and not complete", report.Ratio, report.IsComplete)
+	}
+
+	a.Add(90)
+	b.Add(180)
+	c.Add(270)
+	if report := mp.Snapshot(); report.Done != 600 || !report.IsComplete {
+		t.Fatalf("done %d of %d, want complete", report.Done, report.Total)
+	}
+}
+
+func TestMultiProgressCompletedChild(t *testing.T) {
+	mp := NewMulti().WithReporter(NewNullReporter())
+	a := mp.NewChild(100)
+
+	// total of the child was an overestimate
+	a.Add(10)
+	a.Complete()
+	if report := mp.Snapshot(); report.Ratio != 1 || !report.IsComplete {
+		t.Fatalf("ratio %v, complete %v, want 1 and complete", report.Ratio, report.IsComplete)
+	}
+
+	b := mp.NewChild(300)
+	b.Add(20)
+	report := mp.Snapshot()
+	if report.Done != 120 || report.Total != 400 || report.IsComplete {
+		t.Fatalf("done %d of %d, complete %v, want 120 of 400 and not complete",
+			report.Done, report.Total, report.IsComplete)
+	}
+}
@@ -0,0 +1,16 @@
+package gopv
+
+// NullReporter discards all reports. The reporter loop keeps running with it, so OnReport and
+// OnMilestone hooks are still called and rates are still measured, only nothing is rendered
+type NullReporter struct{}
+
+// NewNullReporter returns a new instance of reporter which discards all reports
+func NewNullReporter() *NullReporter {
+	return &NullReporter{}
+}
+
+// Report does nothing
+func (r *NullReporter) Report(Report) {}
+
+// Finalize does nothing
+func (r *NullReporter) Finalize() {}
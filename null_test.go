@@ -0,0 +1,29 @@
+package gopv
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNullReporterRunsHooks(t *testing.T) {
+	withReportTime(t, time.Millisecond)
+	pv := New(100).WithReporter(NewNullReporter())
+	fired := make(chan int, 3)
+	pv.OnMilestone([]int{25, 50, 75}, func(percent int) {
+		fired <- percent
+	})
+	startManual(t, pv)
+
+	// milestones are detected by the reporter loop on its own, nothing forces a report
+	for _, want := range []int{25, 50, 75} {
+		pv.Set(want)
+		select {
+		case got := <-fired:
+			if got != want {
+				t.Fatalf("milestone %d fired, want %d", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("milestone %d did not fire", want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package gopv
+
+import (
+	"io"
+	"os"
+	"strconv"
+)
+
+// PercentReporter writes integer percent on its own line whenever it changes, e.g. "0\n5\n10\n".
+// It is handy for scripts and gauges of dialog-like tools
+type PercentReporter struct {
+	// config - should be copied in clone()
+	output io.Writer
+
+	// runtime vars. should not be copied in clone()
+	last    int
+	written bool
+}
+
+// NewPercentReporter returns a new instance of reporter writing to stdout
+func NewPercentReporter() *PercentReporter {
+	return &PercentReporter{
+		output: os.Stdout,
+	}
+}
+
+// WithOutput returns a new instance of PercentReporter with custom output
+func (r *PercentReporter) WithOutput(output io.Writer) *PercentReporter {
+	ret := r.clone()
+	ret.output = output
+	return ret
+}
+
+// Report writes the percent if it differs from the last written one. Write errors are discarded
+func (r *PercentReporter) Report(report Report) {
+	r.write(report.PercentInt)
+}
+
+// Finalize writes 100 unless it was already written
+func (r *PercentReporter) Finalize() {
+	r.write(100)
+}
+
+// write writes percent if it differs from the last written one
+func (r *PercentReporter) write(percent int) {
+	if r.written && percent == r.last {
+		return
+	}
+
+	r.last = percent
+	r.written = true
+	_, _ = io.WriteString(r.output, strconv.Itoa(percent)+"\n")
+}
+
+func (r *PercentReporter) clone() *PercentReporter {
+	cp := *r
+	cp.last = 0
+	cp.written = false
+	return &cp
+}
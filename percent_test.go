@@ -0,0 +1,31 @@
+package gopv
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPercentReporter(t *testing.T) {
+	var out bytes.Buffer
+	r := NewPercentReporter().WithOutput(&out)
+	for _, done := range []int64{0, 0, 4, 5, 5, 10, 10, 55, 99} {
+		r.Report(barReport(done, 0, 0))
+	}
+	r.Finalize()
+
+	if got, want := out.String(), "0\n4\n5\n10\n55\n99\n100\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestPercentReporterCompleteOnce(t *testing.T) {
+	var out bytes.Buffer
+	r := NewPercentReporter().WithOutput(&out)
+	r.Report(barReport(50, 0, 0))
+	r.Report(barReport(100, 0, 0))
+	r.Finalize()
+
+	if got, want := out.String(), "50\n100\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package gopv
+
+import (
+	"bufio"
+	"hash"
+	"io"
+)
+
+// progressReader advances progress by the number of bytes read
+type progressReader struct {
+	r io.Reader
+	p *Progress
+}
+
+// NewReader wraps given reader so that every read advances progress by the number of bytes read
+func (p *Progress) NewReader(r io.Reader) io.Reader {
+	return &progressReader{r: r, p: p}
+}
+
+func (pr *progressReader) Read(b []byte) (int, error) {
+	n, err := pr.r.Read(b)
+	if n > 0 {
+		pr.p.Add(n)
+	}
+	return n, err
+}
+
+// HashingReader advances progress by the number of bytes read and feeds them to a hash,
+// so the checksum is computed in the same pass as the data is consumed
+type HashingReader struct {
+	r   io.Reader
+	p   *Progress
+	h   hash.Hash
+	eof bool
+}
+
+// NewHashingReader wraps given reader so that every read advances progress and updates h.
+// The checksum is available via Sum() after the reader reaches EOF
+func (p *Progress) NewHashingReader(r io.Reader, h hash.Hash) *HashingReader {
+	return &HashingReader{r: r, p: p, h: h}
+}
+
+func (hr *HashingReader) Read(b []byte) (int, error) {
+	n, err := hr.r.Read(b)
+	if n > 0 {
+		// hash.Hash never returns an error
+		_, _ = hr.h.Write(b[:n])
+		hr.p.Add(n)
+	}
+	if err == io.EOF {
+		hr.eof = true
+	}
+	return n, err
+}
+
+// Sum returns checksum of all read data. Returns nil until the reader reaches EOF
+func (hr *HashingReader) Sum() []byte {
+	if !hr.eof {
+		return nil
+	}
+	return hr.h.Sum(nil)
+}
+
+// ScanLines reads r line by line and sets the number of done items to the value parsed from
+// each line. Lines for which parse returns false are skipped. It is handy for driving progress
+// from the output of external tools. Returns when r is exhausted
+func (p *Progress) ScanLines(r io.Reader, parse func(line string) (done int, ok bool)) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if done, ok := parse(scanner.Text()); ok {
+			p.Set(done)
+		}
+	}
+	return scanner.Err()
+}
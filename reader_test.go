@@ -0,0 +1,62 @@
+package gopv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestHashingReader(t *testing.T) {
+	const content = "hello, world\n"
+	pv := New(len(content)).WithReporter(NewNullReporter())
+	startManual(t, pv)
+
+	hr := pv.NewHashingReader(iotest.OneByteReader(strings.NewReader(content)), sha256.New())
+	if hr.Sum() != nil {
+		t.Fatal("checksum is available before reading")
+	}
+	data, err := io.ReadAll(hr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Fatalf("read %q, want %q", data, content)
+	}
+
+	const want = "853ff93762a06ddbf722c4ebe9ddd66d8f63ddaea97f521c3ecc20da7c976020"
+	if got := hex.EncodeToString(hr.Sum()); got != want {
+		t.Errorf("checksum %s, want %s", got, want)
+	}
+	if report := pv.Snapshot(); report.Done != len(content) || !report.IsComplete {
+		t.Errorf("done %d of %d, want complete", report.Done, report.Total)
+	}
+}
+
+func TestScanLines(t *testing.T) {
+	pv := New(300).WithReporter(NewNullReporter())
+	startManual(t, pv)
+
+	output := "starting\nProcessed 100\nwarning: slow disk\nProcessed 250\nProcessed 300\ndone\n"
+	var seen []int
+	err := pv.ScanLines(strings.NewReader(output), func(line string) (int, bool) {
+		n, err := strconv.Atoi(strings.TrimPrefix(line, "Processed "))
+		if err != nil {
+			return 0, false
+		}
+		seen = append(seen, n)
+		return n, true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 3 {
+		t.Fatalf("parsed %v, want 3 values", seen)
+	}
+	if report := pv.Snapshot(); report.Done != 300 || !report.IsComplete {
+		t.Fatalf("done %d of %d, want complete", report.Done, report.Total)
+	}
+}
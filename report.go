@@ -0,0 +1,143 @@
+package gopv
+
+import "time"
+
+// reportSpec holds values NewReport builds a report from
+type reportSpec struct {
+	now     time.Time
+	done    int64
+	total   int64
+	errors  int64
+	elapsed time.Duration
+	eta     time.Duration
+	phase   string
+	message string
+	unit    string
+}
+
+// ReportOption sets a value of the report built by NewReport
+type ReportOption func(s *reportSpec)
+
+// WithNow sets the current time of the report. By default it is time.Now()
+func WithNow(now time.Time) ReportOption {
+	return func(s *reportSpec) {
+		s.now = now
+	}
+}
+
+// WithDone sets the number of done items of the report
+func WithDone(done int64) ReportOption {
+	return func(s *reportSpec) {
+		s.done = done
+	}
+}
+
+// WithTotal sets the total number of items of the report
+func WithTotal(total int64) ReportOption {
+	return func(s *reportSpec) {
+		s.total = total
+	}
+}
+
+// WithErrors sets the number of failed items of the report. They are expected to be included in done
+func WithErrors(errors int64) ReportOption {
+	return func(s *reportSpec) {
+		s.errors = errors
+	}
+}
+
+// WithElapsed sets the time elapsed since start of the report
+func WithElapsed(elapsed time.Duration) ReportOption {
+	return func(s *reportSpec) {
+		s.elapsed = elapsed
+	}
+}
+
+// WithETA sets ETA of the report. By default it is estimated linearly from done items and elapsed time
+func WithETA(eta time.Duration) ReportOption {
+	return func(s *reportSpec) {
+		s.eta = eta
+	}
+}
+
+// WithPhase sets the phase of the report
+func WithPhase(phase string) ReportOption {
+	return func(s *reportSpec) {
+		s.phase = phase
+	}
+}
+
+// WithMessage sets the message of the report
+func WithMessage(message string) ReportOption {
+	return func(s *reportSpec) {
+		s.message = message
+	}
+}
+
+// WithReportUnit sets the unit of the report. By default it is UnitItems
+func WithReportUnit(unit string) ReportOption {
+	return func(s *reportSpec) {
+		s.unit = unit
+	}
+}
+
+// NewReport builds a consistent report from given values: ratios, percents, rates and ETA are derived
+// the same way the progress tracker does. It is meant for testing custom reporters
+func NewReport(opts ...ReportOption) Report {
+	s := reportSpec{now: time.Now(), eta: -1, unit: UnitItems}
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	var left int64
+	if s.total > s.done {
+		left = s.total - s.done
+	}
+	var ratio float64
+	if s.total > 0 {
+		ratio = float64(s.done) / float64(s.total)
+	}
+	isComplete := s.total > 0 && s.done >= s.total
+
+	rps := perSecond(s.done, s.elapsed)
+	eta := s.eta
+	if eta < 0 {
+		eta = 0
+		if s.total > 0 && !isComplete {
+			eta = linearETA(left, rps)
+		}
+	}
+
+	var avgItem time.Duration
+	if s.done > 0 {
+		avgItem = s.elapsed / time.Duration(s.done)
+	}
+
+	return Report{
+		Now:             s.now,
+		StartedAt:       s.now.Add(-s.elapsed),
+		Total:           int(s.total),
+		Done:            int(s.done),
+		Left:            int(left),
+		Total64:         s.total,
+		Done64:          s.done,
+		Left64:          left,
+		Errors:          int(s.errors),
+		Ratio:           ratio,
+		PercentInt:      int(ratio * 100),
+		PercentFloat:    ratio * 100,
+		Elapsed:         s.elapsed,
+		ElapsedActive:   s.elapsed,
+		ETA:             eta,
+		RPSAvg:          rps,
+		RPMAvg:          rps * 60,
+		RPSPerWorker:    rps,
+		IsComplete:      isComplete,
+		Phase:           s.phase,
+		Message:         s.message,
+		FinishAt:        finishAt(s.now, eta),
+		AvgItemDuration: avgItem,
+		WillFinish:      true,
+		Unit:            s.unit,
+	}
+}
@@ -0,0 +1,49 @@
+package gopv
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewReport(t *testing.T) {
+	now := time.Date(2023, 12, 2, 8, 52, 24, 0, time.UTC)
+	report := NewReport(WithNow(now), WithDone(30), WithTotal(120), WithErrors(3), WithElapsed(10*time.Second),
+		WithPhase("copy"), WithMessage("a.txt"))
+
+	if report.Left != 90 || report.Left64 != 90 || report.Done64 != 30 || report.Total64 != 120 {
+		t.Errorf("done %d, total %d, left %d", report.Done64, report.Total64, report.Left64)
+	}
+	if report.Ratio != 0.25 || report.PercentInt != 25 || report.PercentFloat != 25 {
+		t.Errorf("ratio %v, percent %d and %v", report.Ratio, report.PercentInt, report.PercentFloat)
+	}
+	if report.RPSAvg != 3 || report.RPMAvg != 180 || report.RPSPerWorker != 3 {
+		t.Errorf("RPS %v, RPM %v, RPS per worker %v", report.RPSAvg, report.RPMAvg, report.RPSPerWorker)
+	}
+	if report.ETA != 30*time.Second || !report.FinishAt.Equal(now.Add(30*time.Second)) {
+		t.Errorf("ETA %v, finish at %v", report.ETA, report.FinishAt)
+	}
+	if !report.StartedAt.Equal(now.Add(-10*time.Second)) || report.ElapsedActive != 10*time.Second {
+		t.Errorf("started at %v, active for %v", report.StartedAt, report.ElapsedActive)
+	}
+	if report.AvgItemDuration != 333333333*time.Nanosecond {
+		t.Errorf("average item duration %v", report.AvgItemDuration)
+	}
+	if report.IsComplete || report.Errors != 3 || report.Phase != "copy" || report.Message != "a.txt" ||
+		report.Unit != UnitItems {
+		t.Errorf("got %+v", report)
+	}
+}
+
+func TestNewReportComplete(t *testing.T) {
+	// done items beyond total do not make left negative
+	report := NewReport(WithDone(130), WithTotal(120), WithElapsed(10*time.Second))
+	if !report.IsComplete || report.Left != 0 || report.ETA != 0 {
+		t.Fatalf("complete %v, left %d, ETA %v", report.IsComplete, report.Left, report.ETA)
+	}
+
+	// explicit ETA takes precedence over the estimation, unknown total gives no ratio
+	report = NewReport(WithDone(30), WithETA(time.Minute), WithReportUnit(UnitBytes))
+	if report.ETA != time.Minute || report.Ratio != 0 || report.IsComplete || report.Unit != UnitBytes {
+		t.Fatalf("got %+v", report)
+	}
+}
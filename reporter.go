@@ -3,10 +3,15 @@ package gopv
 import (
 	"bufio"
 	"fmt"
+	"image/color"
 	"io"
+	"math"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,48 +22,133 @@ type Reporter interface {
 
 type Report struct {
 	// Current time
-	Now time.Time
+	Now time.Time `json:"now"`
 
 	// Time when progress was started
-	StartedAt time.Time
+	StartedAt time.Time `json:"started_at"`
 
 	// Time since last report
-	DT time.Duration
+	DT time.Duration `json:"dt"`
+
+	// Time since last report made by the reporter loop. Same as DT
+	ElapsedSinceLastReport time.Duration `json:"elapsed_since_last_report"`
 
 	// Total number of items
-	Total int
+	Total int `json:"total"`
 
 	// Number of items done
-	Done int
+	Done int `json:"done"`
+
+	// Number of items left. Never negative, even if more items than total are done
+	Left int `json:"left"`
+
+	// Total number of items. Unlike Total it does not overflow on 32-bit platforms
+	Total64 int64 `json:"total64"`
+
+	// Number of items done. Unlike Done it does not overflow on 32-bit platforms
+	Done64 int64 `json:"done64"`
 
-	// Number of items left
-	Left int
+	// Number of items left. Unlike Left it does not overflow on 32-bit platforms
+	Left64 int64 `json:"left64"`
+
+	// Number of failed items, see AddError. Failed items are included in Done
+	Errors int `json:"errors"`
 
 	// Ratio of done items to total
-	Ratio float64
+	Ratio float64 `json:"ratio"`
 
 	// Percent of done items to total
-	PercentInt int
+	PercentInt int `json:"percent_int"`
 
 	// Percent of done items to total
-	PercentFloat float64
+	PercentFloat float64 `json:"percent_float"`
 
 	// Time elapsed since start
-	Elapsed time.Duration
+	Elapsed time.Duration `json:"elapsed"`
+
+	// Sequence number of the report, starting from 1. Gaps mean dropped reports.
+	// Zero for reports returned by Snapshot
+	Seq int `json:"seq"`
+
+	// Time elapsed since start excluding pauses, see Pause
+	ElapsedActive time.Duration `json:"elapsed_active"`
+
+	// Whether the work is paused
+	Paused bool `json:"paused"`
 
 	// Estimated time to finish
-	ETA time.Duration
+	ETA time.Duration `json:"eta"`
 
 	// Average done items per second
-	RPSAvg float64
+	RPSAvg float64 `json:"rps_avg"`
 
 	// Instant RPS(rps since last report)
-	RPSInst float64
+	RPSInst float64 `json:"rps_inst"`
 
 	// Average done items per minute
-	RPMAvg float64
+	RPMAvg float64 `json:"rpm_avg"`
+
+	// Whether all items are done
+	IsComplete bool `json:"is_complete"`
+
+	// Name of the current phase
+	Phase string `json:"phase"`
+
+	// Estimated time of finish. Zero when ETA is unknown
+	FinishAt time.Time `json:"finish_at"`
+
+	// Standard deviation of instant RPS across reports
+	RPSStdDev float64 `json:"rps_std_dev"`
+
+	// Number of items waiting to be processed
+	QueueDepth int `json:"queue_depth"`
+
+	// Number of workers processing items
+	Workers int `json:"workers"`
+
+	// Average done items per second per worker. Equals RPSAvg when number of workers is not set
+	RPSPerWorker float64 `json:"rps_per_worker"`
+
+	// Message describing the current item, see SetMessage
+	Message string `json:"message"`
+
+	// Instant rate in percents of total per second. Zero when total is unknown
+	PercentRate float64 `json:"percent_rate"`
+
+	// Time since items were last added or set when it exceeds the heartbeat, see WithHeartbeat.
+	// Zero otherwise
+	NoActivity time.Duration `json:"no_activity"`
+
+	// Average time per done item, zero when no items are done
+	AvgItemDuration time.Duration `json:"avg_item_duration"`
+
+	// Secondary position, see SetSecondary
+	Secondary int64 `json:"secondary"`
+
+	// Ratio of secondary position to total
+	SecondaryRatio float64 `json:"secondary_ratio"`
+
+	// Label of the progress tracker, see WithLabel and LabelKey
+	Label string `json:"label"`
+
+	// Whether the work is expected to finish by the deadline, see WithDeadline. False while ETA
+	// is unknown, e.g. nothing is done yet. Always true when no deadline is set
+	WillFinish bool `json:"will_finish"`
+
+	// Unit of Done and Total: UnitItems, UnitBytes, UnitDuration (nanoseconds) or a custom label
+	Unit string `json:"unit"`
 }
 
+// SpeedUnit selects how {speed} placeholder renders the rate
+type SpeedUnit int
+
+const (
+	// SpeedUnitItems renders rate as items per second with SI prefixes, e.g. "1.2k items/s"
+	SpeedUnitItems SpeedUnit = iota
+	// SpeedUnitBytes renders rate as bytes per second, e.g. "12.0 MiB/s"
+	SpeedUnitBytes
+)
+
 // TextReporter is a simple reporter that writes reports to given output.
 //
 // Default Legend:
@@ -72,15 +162,86 @@ type Report struct {
 // To customize legend see WithLegend()
 type TextReporter struct {
 	// config - should be copied in clone()
-	legend         string
-	floatPrecision int
-	output         io.Writer
-	pbWidth        int
+	legend          string
+	floatPrecision  int
+	precisions      map[string]int
+	aligns          map[string]fieldAlign
+	output          io.Writer
+	pbWidth         int
+	refreshOnResize bool
+	siUnits         bool
+	stickyBottom    bool
+	nowFunc         func() time.Time
+	strictLegend    bool
+	gradient        *[2]color.RGBA
+	errorColor      *color.RGBA
+	minLineWidth    int
+	quietUntil      time.Duration
+	barStyle        BarStyle
+	renderFunc      func(cell int, total int, ratio float64) rune
+	lineWriter      *LineWriter
+	byteMode        bool
+	hideSpeed       bool
+	speedUnit       SpeedUnit
+	tabular         bool
+	logEvery        int
+	logEveryPercent float64
+	bellOnComplete  bool
+	spinnerFrames   []string
+	spinnerInterval time.Duration
+	messageTruncate TruncateMode
+	messageWidth    int
+	timeZone        *time.Location
+	completionMsg   func(Report) string
+	updateStrategy  UpdateStrategy
+	animatedFill    bool
+	forceTTY        *bool
+	adaptiveETA     bool
+	fullscreen      bool
+	rpsScaled       bool
+	tickMarks       []float64
+	tickChar        rune
+	lazyFlush       bool
+	defaultSummary  bool
 
 	// runtime vars. should not be copied in clone()
+	// mu serializes rendering, so RenderString can be called concurrently with Report
+	mu *sync.Mutex
+	textReporterState
+}
+
+// textReporterState is the runtime state of TextReporter. Copies made by clone() start with
+// zero state
+type textReporterState struct {
 	legendCompiled   string
+	lineSlot         int
+	hasLineSlot      bool
 	writer           *bufio.Writer
 	lastLegendLength int
+	termWidth        int
+	resized          int32
+	stopResize       func()
+	spinnerFrame     int
+	termHeight       int
+	tty              bool
+	ansi             bool
+	colorMode        colorMode
+	sticky           bool
+	screen           bool
+	lastLegend       string
+	columnWidths     []int
+	pendingReport    *Report
+	reportIndex      int
+	loggedPercent    float64
+	complete         bool
+	lastReport       *Report
+	forceRedraw      bool
+	rewindPending    bool
+	displayedRatio   float64
+	lineWidth        int
+	segments         *compiledLegend
+	buf              []byte
+	args             []any
 }
 
 const (
@@ -88,12 +249,25 @@ const (
 	TextReporterLegendDefault = "[{now}] - working ({done}/{total}) done {percent_int}%%, RPS {rps_avg}, elapsed {elapsed}, ETA {eta}\r"
 	// TextReporterLegendProgressBar TextReporter legend with progress bar
 	TextReporterLegendProgressBar = "{progress_bar} {percent_int}%%, {rps_avg} RPS, {eta} ETA\r"
+	// TextReporterLegendBytes TextReporter legend for byte transfers. Use it with WithByteMode(true)
+	TextReporterLegendBytes = "{progress_bar} {done_bytes}/{total_bytes}, {rps_bytes}, ETA {eta}\r"
 	// TextReporterDefaultFloatPrecision is the default float precision for ann floats in TextReporter
 	TextReporterDefaultFloatPrecision = 2
 	// TextReporterDefaultProgressBarWidth is the default progress bar with for TextReporter
 	TextReporterDefaultProgressBarWidth = 80
+	// TextReporterTimeFormat is the format of all timestamps rendered by TextReporter
+	TextReporterTimeFormat = "2006-01-02 03:04:05"
+	// TextReporterUnknown is rendered in place of values which can not be estimated yet
+	TextReporterUnknown = "unknown"
+	// TextReporterDoneMarker is rendered by {done_marker} when all items are done
+	TextReporterDoneMarker = "✓"
+	// TextReporterDeadlineRisk is rendered by {deadline_risk} when the work is not going to finish by the deadline
+	TextReporterDeadlineRisk = "!"
 )
 
+// spinnerFrames are rendered by {done_marker} while progress is not complete
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
 // NewTextReporter returns a new instance of reporter
 func NewTextReporter() *TextReporter {
 	return &TextReporter{
@@ -101,6 +275,9 @@ func NewTextReporter() *TextReporter {
 		floatPrecision: TextReporterDefaultFloatPrecision,
 		output:         os.Stderr,
 		pbWidth:        TextReporterDefaultProgressBarWidth,
+		barStyle:       BarStyleASCII,
+		spinnerFrames:  spinnerFrames,
+		mu:             &sync.Mutex{},
 	}
 }
 
@@ -118,6 +295,62 @@ func (r *TextReporter) WithFloatPrecision(floatPrecision int) *TextReporter {
 	return ret
 }
 
+// WithPlaceholderPrecision returns a new instance of TextReporter with custom precision of a single
+// float placeholder, e.g. WithPlaceholderPrecision("{rps_avg}", 0). Other floats use float precision
+func (r *TextReporter) WithPlaceholderPrecision(placeholder string, precision int) *TextReporter {
+	ret := r.clone()
+	ret.precisions = make(map[string]int, len(r.precisions)+1)
+	for k, v := range r.precisions {
+		ret.precisions[k] = v
+	}
+	ret.precisions[placeholder] = precision
+	return ret
+}
+
+// Align selects how WithFieldAlign justifies placeholder values
+type Align int
+
+const (
+	// AlignRight pads values with spaces on the left
+	AlignRight Align = iota
+	// AlignLeft pads values with spaces on the right
+	AlignLeft
+)
+
+// fieldAlign is the alignment of a placeholder
+type fieldAlign struct {
+	align Align
+	width int
+}
+
+// WithFieldAlign returns a new instance of TextReporter padding values of given placeholder with
+// spaces to width characters, e.g. WithFieldAlign("{percent_int}", AlignRight, 3) keeps the percent
+// in place while it grows. Longer values are not truncated
+func (r *TextReporter) WithFieldAlign(placeholder string, align Align, width int) *TextReporter {
+	ret := r.clone()
+	ret.aligns = make(map[string]fieldAlign, len(r.aligns)+1)
+	for k, v := range r.aligns {
+		ret.aligns[k] = v
+	}
+	ret.aligns[placeholder] = fieldAlign{align: align, width: width}
+	return ret
+}
+
+// WithPercentDecimals returns a new instance of TextReporter with custom precision of {percent_float}
+func (r *TextReporter) WithPercentDecimals(precision int) *TextReporter {
+	return r.WithPlaceholderPrecision("{percent_float}", precision)
+}
+
+// WithRPSDecimals returns a new instance of TextReporter with custom precision of rate placeholders
+func (r *TextReporter) WithRPSDecimals(precision int) *TextReporter {
+	return r.
+		WithPlaceholderPrecision("{rps_avg}", precision).
+		WithPlaceholderPrecision("{rps_inst}", precision).
+		WithPlaceholderPrecision("{rpm}", precision).
+		WithPlaceholderPrecision("{rps_stddev}", precision).
+		WithPlaceholderPrecision("{rps_per_worker}", precision)
+}
+
 // WithOutput return a new instance of TextReporter with custom output
 func (r *TextReporter) WithOutput(output io.Writer) *TextReporter {
 	ret := r.clone()
@@ -125,6 +358,22 @@ func (r *TextReporter) WithOutput(output io.Writer) *TextReporter {
 	return ret
 }
 
+// WithOutputSynced returns a new instance of TextReporter with custom output shared with other writers.
+// Every write to the output is done while holding mu, so the application can serialize its own
+// writes to the same output by locking mu as well.
+func (r *TextReporter) WithOutputSynced(output io.Writer, mu *sync.Mutex) *TextReporter {
+	return r.WithOutput(&lockedWriter{w: output, mu: mu})
+}
+
+// WithLineWriter returns a new instance of TextReporter writing to its own line of given LineWriter.
+// Several reporters sharing a LineWriter render on distinct lines. Lines are assigned in the order
+// of the first reports
+func (r *TextReporter) WithLineWriter(lw *LineWriter) *TextReporter {
+	ret := r.clone()
+	ret.lineWriter = lw
+	return ret
+}
+
 // WithProgressBarWidth returns a new instance of TextReporter with given progress bar width
 func (r *TextReporter) WithProgressBarWidth(width int) *TextReporter {
 	ret := r.clone()
@@ -132,105 +381,1281 @@ func (r *TextReporter) WithProgressBarWidth(width int) *TextReporter {
 	return ret
 }
 
+// WithRefreshOnResize returns a new instance of TextReporter which recomputes width-dependent
+// parts of the legend when the terminal is resized. Progress bar is shrunk to fit the space left
+// in the terminal line by the rest of the legend.
+func (r *TextReporter) WithRefreshOnResize(refresh bool) *TextReporter {
+	ret := r.clone()
+	ret.refreshOnResize = refresh
+	return ret
+}
+
+// WithByteMode returns a new instance of TextReporter tuned for byte transfers. Fast transfers
+// finish in seconds, so ETA under 10 seconds is rendered with sub-second precision.
+// See TextReporterLegendBytes
+func (r *TextReporter) WithByteMode(byteMode bool) *TextReporter {
+	ret := r.clone()
+	ret.byteMode = byteMode
+	return ret
+}
+
+// WithShowSpeed returns a new instance of TextReporter which renders or hides {speed}.
+// Speed is shown by default
+func (r *TextReporter) WithShowSpeed(show bool) *TextReporter {
+	ret := r.clone()
+	ret.hideSpeed = !show
+	return ret
+}
+
+// WithSpeedUnit returns a new instance of TextReporter rendering {speed} in given units
+func (r *TextReporter) WithSpeedUnit(unit SpeedUnit) *TextReporter {
+	ret := r.clone()
+	ret.speedUnit = unit
+	return ret
+}
+
+// WithTabularAlignment returns a new instance of TextReporter which treats tabs in the legend as
+// column separators. Every column is padded to the widest value it has had so far, so columns
+// stay in place across reports. Tabs are rendered as single spaces
+func (r *TextReporter) WithTabularAlignment(tabular bool) *TextReporter {
+	ret := r.clone()
+	ret.tabular = tabular
+	return ret
+}
+
+// WithAdaptiveETARounding returns a new instance of TextReporter rounding ETA according to its
+// magnitude: to seconds under a minute, to 5 seconds under an hour and to minutes above
+func (r *TextReporter) WithAdaptiveETARounding(adaptive bool) *TextReporter {
+	ret := r.clone()
+	ret.adaptiveETA = adaptive
+	return ret
+}
+
+// WithForceTTY returns a new instance of TextReporter which treats output as a terminal or not
+// regardless of detection. Escape sequences are still written only to terminals supporting them
+func (r *TextReporter) WithForceTTY(tty bool) *TextReporter {
+	ret := r.clone()
+	ret.forceTTY = &tty
+	return ret
+}
+
+// WithLogEvery returns a new instance of TextReporter which renders only every nth report when
+// output is not a terminal, e.g. a log file. The last report is always rendered
+func (r *TextReporter) WithLogEvery(n int) *TextReporter {
+	ret := r.clone()
+	ret.logEvery = n
+	return ret
+}
+
+// WithLogEveryPercent returns a new instance of TextReporter which renders a report only when
+// percent has advanced by at least p since the last rendered one and output is not a terminal.
+// The last report is always rendered
+func (r *TextReporter) WithLogEveryPercent(p float64) *TextReporter {
+	ret := r.clone()
+	ret.logEveryPercent = p
+	return ret
+}
+
+// WithSIUnits returns a new instance of TextReporter which renders byte placeholders using
+// decimal units (kB, MB, GB) instead of default binary units (KiB, MiB, GiB)
+func (r *TextReporter) WithSIUnits(si bool) *TextReporter {
+	ret := r.clone()
+	ret.siUnits = si
+	return ret
+}
+
+// WithRPSScaled returns a new instance of TextReporter rendering {rps_avg} with SI prefixes like
+// {rps_scaled}, e.g. "5.1M/s" instead of "5123456.00"
+func (r *TextReporter) WithRPSScaled(scaled bool) *TextReporter {
+	ret := r.clone()
+	ret.rpsScaled = scaled
+	return ret
+}
+
+// WithFullscreen returns a new instance of TextReporter which clears the screen and draws the legend
+// in its center, e.g. for kiosk-style displays of a single task. The screen is restored on Finalize
+// and the last legend is left in the terminal. Has no effect when output is not a terminal or the
+// terminal does not support ANSI escape sequences
+func (r *TextReporter) WithFullscreen(fullscreen bool) *TextReporter {
+	ret := r.clone()
+	ret.fullscreen = fullscreen
+	return ret
+}
+
+// WithStickyBottom returns a new instance of TextReporter which keeps the legend pinned to the
+// bottom line of the terminal. Other output written to the terminal scrolls above the legend.
+// Has no effect when output is not a terminal or the terminal does not support ANSI escape sequences.
+func (r *TextReporter) WithStickyBottom(sticky bool) *TextReporter {
+	ret := r.clone()
+	ret.stickyBottom = sticky
+	return ret
+}
+
+// WithNowFunc returns a new instance of TextReporter which renders {now} using given function
+// instead of the report time. {started_at} is shifted by the same offset. Rates and durations
+// are not affected, which makes it handy for golden tests with a frozen timestamp.
+func (r *TextReporter) WithNowFunc(nowFunc func() time.Time) *TextReporter {
+	ret := r.clone()
+	ret.nowFunc = nowFunc
+	return ret
+}
+
+// WithStrictLegend returns a new instance of TextReporter which panics on the first report
+// if the legend contains unknown placeholders. See ValidateLegend()
+func (r *TextReporter) WithStrictLegend(strict bool) *TextReporter {
+	ret := r.clone()
+	ret.strictLegend = strict
+	return ret
+}
+
+// WithGradient returns a new instance of TextReporter which colors filled cells of the progress bar
+// with a gradient from the first cell to the last one. Terminals without true color support get
+// the nearest 256-color palette colors. Has no effect when output is not a terminal.
+func (r *TextReporter) WithGradient(from, to color.RGBA) *TextReporter {
+	ret := r.clone()
+	ret.gradient = &[2]color.RGBA{from, to}
+	return ret
+}
+
+// WithErrorColor returns a new instance of TextReporter which colors cells of failed items in the
+// progress bar, see AddError. Has no effect when output is not a terminal
+func (r *TextReporter) WithErrorColor(c color.RGBA) *TextReporter {
+	ret := r.clone()
+	ret.errorColor = &c
+	return ret
+}
+
+// WithMinLineWidth returns a new instance of TextReporter which pads every rendered line with spaces
+// to at least width columns
+func (r *TextReporter) WithMinLineWidth(width int) *TextReporter {
+	ret := r.clone()
+	ret.minLineWidth = width
+	return ret
+}
+
+// WithQuietUntil returns a new instance of TextReporter which renders nothing until d has
+// elapsed since start. Tasks finished faster than d produce no output at all
+func (r *TextReporter) WithQuietUntil(d time.Duration) *TextReporter {
+	ret := r.clone()
+	ret.quietUntil = d
+	return ret
+}
+
+// WithBarBrackets returns a new instance of TextReporter with custom progress bar delimiters.
+// Empty strings mean no delimiters. Delimiters are included in the progress bar width
+func (r *TextReporter) WithBarBrackets(left, right string) *TextReporter {
+	ret := r.clone()
+	ret.barStyle.Left = left
+	ret.barStyle.Right = right
+	return ret
+}
+
+// WithBarChars returns a new instance of TextReporter drawing done and remaining cells of the
+// progress bar with given characters
+func (r *TextReporter) WithBarChars(fill, empty string) *TextReporter {
+	ret := r.clone()
+	ret.barStyle.Fill = fill
+	ret.barStyle.Head = ""
+	ret.barStyle.Empty = empty
+	return ret
+}
+
+// WithAnimatedFill returns a new instance of TextReporter which moves the progress bar fill towards
+// the actual progress by a part of the distance on every render, so large steps are smoothed out.
+// The fill never gets ahead of the actual progress and is full as soon as the work is complete
+func (r *TextReporter) WithAnimatedFill(animated bool) *TextReporter {
+	ret := r.clone()
+	ret.animatedFill = animated
+	return ret
+}
+
+// WithBarErrorChar returns a new instance of TextReporter drawing cells of failed items in the
+// progress bar with given character. Empty string means failed items are drawn as done ones
+func (r *TextReporter) WithBarErrorChar(char string) *TextReporter {
+	ret := r.clone()
+	ret.barStyle.Error = char
+	return ret
+}
+
+// WithFlushOnFinalizeOnly returns a new instance of TextReporter which does not flush the output
+// after every report when it is not a terminal, e.g. a log file. Output is written when the buffer
+// fills up and on Finalize, which saves syscalls of long runs
+func (r *TextReporter) WithFlushOnFinalizeOnly(finalizeOnly bool) *TextReporter {
+	ret := r.clone()
+	ret.lazyFlush = finalizeOnly
+	return ret
+}
+
+// WithTickMarks returns a new instance of TextReporter drawing given character at given ratios of
+// the progress bar, e.g. []float64{0.25, 0.5, 0.75} for quartiles. Ticks are drawn over empty cells
+// only, the filled part of the bar hides them
+func (r *TextReporter) WithTickMarks(positions []float64, char rune) *TextReporter {
+	ret := r.clone()
+	ret.tickMarks = append([]float64(nil), positions...)
+	ret.tickChar = char
+	return ret
+}
+
+// WithBarStyle returns a new instance of TextReporter with named bar style, see RegisterBarStyle.
+// Panics if the style is not registered
+func (r *TextReporter) WithBarStyle(name string) *TextReporter {
+	style, ok := LookupBarStyle(name)
+	if !ok {
+		panic("unknown bar style " + name)
+	}
+
+	ret := r.clone()
+	ret.barStyle = style
+	return ret
+}
+
+// WithRenderFunc returns a new instance of TextReporter drawing every cell of the progress bar
+// with given function. It is called with cell index, number of cells and progress ratio and
+// overrides bar style characters and gradient. Delimiters are still drawn
+func (r *TextReporter) WithRenderFunc(fn func(cell int, total int, ratio float64) rune) *TextReporter {
+	ret := r.clone()
+	ret.renderFunc = fn
+	return ret
+}
+
+// WithSpinnerFrames returns a new instance of TextReporter rendering given frames by {done_marker}
+// while progress is not complete. Panics if frames are empty
+func (r *TextReporter) WithSpinnerFrames(frames []string) *TextReporter {
+	if len(frames) == 0 {
+		panic("spinner frames should not be empty")
+	}
+
+	ret := r.clone()
+	ret.spinnerFrames = append([]string(nil), frames...)
+	return ret
+}
+
+// WithSpinnerInterval returns a new instance of TextReporter advancing the spinner every d of
+// elapsed time instead of every rendered report
+func (r *TextReporter) WithSpinnerInterval(d time.Duration) *TextReporter {
+	ret := r.clone()
+	ret.spinnerInterval = d
+	return ret
+}
+
+// WithMessageTruncate returns a new instance of TextReporter truncating {message} wider than width
+// columns with given mode. Zero width means the space left in the terminal line by the rest of the
+// legend, the message is not truncated then if the output is not a terminal
+func (r *TextReporter) WithMessageTruncate(mode TruncateMode, width int) *TextReporter {
+	ret := r.clone()
+	ret.messageTruncate = mode
+	ret.messageWidth = width
+	return ret
+}
+
+// WithTimeZone returns a new instance of TextReporter rendering timestamps in given time zone,
+// e.g. time.UTC. By default timestamps are rendered in their own location, which is local time
+func (r *TextReporter) WithTimeZone(loc *time.Location) *TextReporter {
+	ret := r.clone()
+	ret.timeZone = loc
+	return ret
+}
+
+// WithCompletionMessage returns a new instance of TextReporter writing the line returned by fn
+// on Finalize, e.g. "✓ Imported 10000 records in 2m13s". fn is called with the last report
+func (r *TextReporter) WithCompletionMessage(fn func(Report) string) *TextReporter {
+	ret := r.clone()
+	ret.completionMsg = fn
+	return ret
+}
+
+// WithDefaultSummary returns a new instance of TextReporter writing a summary line on Finalize
+// unless a completion message is set, e.g. "Done: 10,000 items in 2m13s", see FormatSummary
+func (r *TextReporter) WithDefaultSummary(summary bool) *TextReporter {
+	ret := r.clone()
+	ret.defaultSummary = summary
+	return ret
+}
+
+// completionMessage returns the function rendering the line written on Finalize, nil for none
+func (r *TextReporter) completionMessage() func(Report) string {
+	if r.completionMsg == nil && r.defaultSummary {
+		return FormatSummary
+	}
+	return r.completionMsg
+}
+
+// UpdateStrategy selects how TextReporter returns to the start of the line to render the next report
+type UpdateStrategy int
+
+const (
+	// UpdateCarriageReturn renders legends as is, they end with \r. This is the default strategy
+	UpdateCarriageReturn UpdateStrategy = iota
+	// UpdateBackspace moves the cursor back with backspaces before rendering the next report. It is
+	// meant for dumb terminals which do not handle \r well
+	UpdateBackspace
+	// UpdateANSIClearLine returns to the start of the line and clears it with an escape sequence
+	// before rendering the next report
+	UpdateANSIClearLine
+)
+
+// WithUpdateStrategy returns a new instance of TextReporter which returns to the start of the line
+// with given strategy. Only legends ending with \r are affected
+func (r *TextReporter) WithUpdateStrategy(strategy UpdateStrategy) *TextReporter {
+	ret := r.clone()
+	ret.updateStrategy = strategy
+	return ret
+}
+
+// WithBellOnComplete returns a new instance of TextReporter ringing the terminal bell on Finalize
+// if the last report was complete. Nothing is written when the output is not a terminal
+func (r *TextReporter) WithBellOnComplete(bell bool) *TextReporter {
+	ret := r.clone()
+	ret.bellOnComplete = bell
+	return ret
+}
+
 // Report renders report
 func (r *TextReporter) Report(report Report) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.complete = report.IsComplete
+	r.lastReport = &report
+	if report.Elapsed < r.quietUntil && r.writer == nil {
+		return
+	}
+
 	if r.legendCompiled == "" {
-		r.legendCompiled = r.compileLegend(r.legend, r.floatPrecision)
+		if r.strictLegend {
+			if err := r.ValidateLegend(); err != nil {
+				panic(err.Error())
+			}
+		}
 		r.writer = bufio.NewWriter(r.output)
+		r.tty = isTerminal(r.output)
+		if r.forceTTY != nil {
+			r.tty = *r.forceTTY
+		}
+		r.ansi = r.tty && supportsANSI(r.output)
+		r.colorMode = detectColorMode(r.ansi)
+		r.screen = r.fullscreen && r.ansi && r.lineWriter == nil
+		r.sticky = r.stickyBottom && r.ansi && r.lineWriter == nil && !r.screen
+		if r.screen {
+			r.writeString(enterScreenSequence)
+		}
+		r.refresh()
+		if r.refreshOnResize || r.sticky {
+			r.stopResize = watchResize(func() {
+				atomic.StoreInt32(&r.resized, 1)
+			})
+		}
+	} else if atomic.CompareAndSwapInt32(&r.resized, 1, 0) {
+		r.refresh()
 	}
 
-	eta := report.ETA.Round(time.Second)
-	if eta <= 0 {
-		eta = 0
-	}
-
-	progressBar := r.renderProgressBar(report)
-
-	legend := fmt.Sprintf(r.legendCompiled,
-		report.Now.Format("2006-01-02 03:04:05"),
-		report.StartedAt.Format("2006-01-02 03:04:05"),
-		report.DT.Round(time.Millisecond),
-		report.Total,
-		report.Done,
-		report.Left,
-		report.Ratio,
-		report.PercentInt,
-		report.PercentFloat,
-		report.Elapsed.Round(time.Second),
-		eta,
-		report.RPSAvg,
-		report.RPSInst,
-		report.RPMAvg,
-		progressBar,
-	)
-	lineLength := len(legend)
+	if r.skipLogLine(report) {
+		r.pendingReport = &report
+		return
+	}
+	r.pendingReport = nil
 
-	r.writeString(legend)
+	r.render(report)
+}
+
+// redraw makes the next render clear the line and render it in full
+func (r *TextReporter) redraw() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.forceRedraw = true
+	r.pendingReport = nil
+	r.reportIndex = 0
+}
+
+// RenderString returns legend rendered for given report without line ending. Nothing is written
+// to the output and the reporter is not changed, so it can be used to embed the legend into another
+// UI and called from any goroutine. Animations advanced by reports, like the spinner without
+// WithSpinnerInterval and the animated fill, stay at their current frame
+func (r *TextReporter) RenderString(report Report) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// render with a copy of the runtime state, so the reporter keeps its own
+	c := *r
+	c.buf = nil
+	c.args = nil
+	c.columnWidths = append([]int(nil), r.columnWidths...)
+
+	format := c.legendCompiled
+	if format == "" {
+		format = c.compileLegend(c.legend)
+	}
+	return strings.TrimRight(c.formatLegend(format, report), "\r\n")
+}
+
+// render renders report to the output
+func (r *TextReporter) render(report Report) {
+	legend := r.formatLegend(r.legendCompiled, report)
+	r.lastLegend = legend
+	if r.lineWriter != nil {
+		if !r.hasLineSlot {
+			r.lineSlot = r.lineWriter.allocate()
+			r.hasLineSlot = true
+		}
+		r.lineWriter.set(r.lineSlot, strings.TrimRight(legend, "\r\n"), report.IsComplete)
+		return
+	}
+
+	if r.sticky {
+		r.writeSticky(legend)
+		r.flush()
+		return
+	}
+
+	if r.screen {
+		r.writeCentered(strings.TrimRight(legend, "\r\n"))
+		r.flush()
+		return
+	}
+
+	if r.forceRedraw {
+		// the line may have been overwritten, start it over
+		r.forceRedraw = false
+		if r.ansi {
+			r.writeString("\r" + eraseLineSequence)
+		} else if r.tty {
+			r.writeString("\r" + strings.Repeat(" ", r.lastLegendLength) + "\r")
+		}
+		r.lastLegendLength = 0
+	}
+
+	// pad the line before its trailing \r or \n, so the cursor ends up where the legend expects
+	line := strings.TrimRight(legend, "\r\n")
+	ending := legend[len(line):]
+	lineLength := displayWidth(line)
+
+	// with other strategies the line is rewound before the next render instead of right after it
+	rewind := ending == "\r" && r.updateStrategy != UpdateCarriageReturn
+	if rewind {
+		ending = ""
+	}
+	if r.rewindPending {
+		switch r.updateStrategy {
+		case UpdateBackspace:
+			r.writeString(strings.Repeat("\b", r.lastLegendLength))
+		case UpdateANSIClearLine:
+			r.writeString("\r" + eraseLineSequence)
+		}
+	}
+	r.rewindPending = rewind
 
-	if r.lastLegendLength > lineLength {
-		spaces := strings.Repeat(" ", r.lastLegendLength-lineLength)
-		r.writeString(spaces)
+	r.writeString(line)
+
+	// terminals erase the rest of the previous longer line themselves, others get it overwritten
+	// with spaces
+	erase := r.ansi && r.updateStrategy != UpdateANSIClearLine
+	padTo := r.minLineWidth
+	if !r.ansi && r.updateStrategy != UpdateANSIClearLine && r.lastLegendLength > padTo {
+		padTo = r.lastLegendLength
+	}
+	if padTo > lineLength {
+		r.writeString(strings.Repeat(" ", padTo-lineLength))
+		lineLength = padTo
 	}
+	if erase {
+		r.writeString(eraseLineSequence)
+	}
+
+	r.writeString(ending)
 
 	r.lastLegendLength = lineLength
-	r.flush()
+	if !r.lazyFlush || r.tty {
+		r.flush()
+	}
+}
+
+// formatLegend renders report with given compiled legend
+func (r *TextReporter) formatLegend(format string, report Report) string {
+	compiled := r.compiledLegend(format)
+	v := r.legendValues(report)
+
+	// only arguments used by the legend are computed
+	if cap(r.args) < len(legendPlaceholders) {
+		r.args = make([]any, len(legendPlaceholders))
+	}
+	args := r.args[:len(legendPlaceholders)]
+	for i := range args {
+		args[i] = nil
+		if i == progressBarArg && r.termWidth > 0 {
+			// rendered below in the space left by the rest of the line
+			args[i] = ""
+			continue
+		}
+		if compiled.uses(i) {
+			args[i] = r.legendArg(i, v)
+		}
+	}
+	if r.termWidth > 0 && compiled.uses(progressBarArg) {
+		args[progressBarArg] = r.renderProgressBar(report, r.fitProgressBar(compiled, args))
+	}
+	if r.messageTruncate != TruncateNone && compiled.uses(messageArg) {
+		args[messageArg] = r.truncateMessage(compiled, args, report.Message)
+	}
+
+	r.buf = compiled.appendLegend(r.buf[:0], args)
+	legend := string(r.buf)
+	if report.NoActivity > 0 {
+		// make hung jobs stand out whatever the legend is
+		line := strings.TrimRight(legend, "\r\n")
+		legend = line + " (no activity for " + report.NoActivity.Round(time.Second).String() + ")" + legend[len(line):]
+	}
+	if r.tabular {
+		legend = r.alignColumns(legend)
+	}
+
+	return legend
+}
+
+// legendValues returns values shared by several legend arguments of the report
+func (r *TextReporter) legendValues(report Report) legendValues {
+	v := legendValues{report: report, now: report.Now, startedAt: report.StartedAt, finishAt: report.FinishAt}
+	v.eta = report.ETA.Round(time.Second)
+	if r.adaptiveETA {
+		v.eta = roundETA(report.ETA)
+	} else if r.byteMode {
+		v.eta = roundShortDuration(report.ETA)
+	}
+	if v.eta <= 0 {
+		v.eta = 0
+	}
+	if r.nowFunc != nil {
+		v.now = r.nowFunc()
+		v.startedAt = v.startedAt.Add(v.now.Sub(report.Now))
+		v.finishAt = v.finishAt.Add(v.now.Sub(report.Now))
+	}
+	return v
+}
+
+// formatTime formats timestamp in the configured time zone
+func (r *TextReporter) formatTime(t time.Time) string {
+	if r.timeZone != nil {
+		t = t.In(r.timeZone)
+	}
+	return t.Format(TextReporterTimeFormat)
+}
+
+// legendValues are values shared by several legend arguments
+type legendValues struct {
+	report    Report
+	now       time.Time
+	startedAt time.Time
+	finishAt  time.Time
+	eta       time.Duration
+}
+
+// legendArg returns the legend argument with given index, which is one less than the argument
+// number in legendPlaceholders specifiers
+func (r *TextReporter) legendArg(i int, v legendValues) any {
+	report := v.report
+	switch i {
+	case 0:
+		return r.formatTime(v.now)
+	case 1:
+		return r.formatTime(v.startedAt)
+	case 2:
+		return report.DT.Round(time.Millisecond)
+	case 3:
+		return r.quantity(report, report.Total64)
+	case 4:
+		return r.quantity(report, report.Done64)
+	case 5:
+		return r.quantity(report, report.Left64)
+	case 6:
+		return report.Ratio
+	case 7:
+		return report.PercentInt
+	case 8:
+		return report.PercentFloat
+	case 9:
+		return report.Elapsed.Round(time.Second)
+	case 10:
+		return v.eta
+	case 11:
+		return report.RPSAvg
+	case 12:
+		return report.RPSInst
+	case 13:
+		return report.RPMAvg
+	case 14:
+		return r.renderProgressBar(report, r.pbWidth)
+	case 15:
+		return r.renderDoneMarker(report)
+	case 16:
+		return r.formatBytes(float64(report.Done64))
+	case 17:
+		return r.formatBytes(float64(report.Total64))
+	case 18:
+		return r.formatBytes(report.RPSAvg) + "/s"
+	case 19:
+		return report.Phase
+	case 20:
+		if report.FinishAt.IsZero() {
+			return TextReporterUnknown
+		}
+		return r.formatTime(v.finishAt)
+	case 21:
+		return report.RPSStdDev
+	case 22:
+		elapsedOfTotal := report.Elapsed.Round(time.Second).String() + " / "
+		if report.ETA > 0 {
+			return elapsedOfTotal + "~" + (report.Elapsed + report.ETA).Round(time.Second).String()
+		}
+		return elapsedOfTotal + TextReporterUnknown
+	case 23:
+		return report.QueueDepth
+	case 24:
+		return FormatDurationCompact(v.eta)
+	case 25:
+		return FormatDurationCompact(report.Elapsed)
+	case 26:
+		return r.renderSpeed(report)
+	case 27:
+		return report.RPSPerWorker
+	case 28:
+		if report.ETA > 0 || report.IsComplete {
+			return FormatFuzzyDuration(report.ETA)
+		}
+		return TextReporterUnknown
+	case 29:
+		return report.Errors
+	case 30:
+		if !report.WillFinish {
+			return TextReporterDeadlineRisk
+		}
+		return ""
+	case messageArg:
+		return report.Message
+	case 32:
+		return r.formatBytes(float64(report.Left64))
+	case 33:
+		return report.Label
+	case 34:
+		return report.PercentRate
+	case 35:
+		if report.AvgItemDuration < 10*time.Millisecond {
+			return report.AvgItemDuration.Round(time.Microsecond)
+		}
+		return roundShortDuration(report.AvgItemDuration)
+	case 36:
+		return report.ElapsedActive.Round(time.Second)
+	case 37:
+		return FormatSIRate(report.RPSAvg)
+	case 38:
+		return report.Seq
+	}
+	return nil
+}
+
+// truncateMessage truncates the message to the configured width. Without configured width the
+// message gets the space left in the terminal line by the rest of the legend
+func (r *TextReporter) truncateMessage(compiled *compiledLegend, args []any, message string) string {
+	width := r.messageWidth
+	if width == 0 {
+		if r.lineWidth == 0 {
+			return message
+		}
+
+		rest := append([]any(nil), args...)
+		rest[messageArg] = ""
+		// leave the last column empty, so the line does not wrap
+		r.buf = compiled.appendLegend(r.buf[:0], rest)
+		width = r.lineWidth - displayWidth(strings.TrimRight(string(r.buf), "\r\n")) - 1
+	}
+
+	return TruncateString(message, width, r.messageTruncate)
+}
+
+// fitProgressBar returns the progress bar width clamped to the space left in the terminal line by
+// the rest of the legend. The message truncated to the terminal width gives its space to the bar
+func (r *TextReporter) fitProgressBar(compiled *compiledLegend, args []any) int {
+	message := args[messageArg]
+	if r.messageTruncate != TruncateNone && r.messageWidth == 0 {
+		args[messageArg] = ""
+	}
+	// leave the last column empty, so the line does not wrap
+	r.buf = compiled.appendLegend(r.buf[:0], args)
+	args[messageArg] = message
+	width := r.termWidth - displayWidth(strings.TrimRight(string(r.buf), "\r\n")) - 1
+	if width > r.pbWidth {
+		width = r.pbWidth
+	}
+	return width
 }
 
 func (r *TextReporter) Finalize() {
-	r.writeString("\n")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pendingReport != nil {
+		// the last report is always rendered
+		r.render(*r.pendingReport)
+		r.pendingReport = nil
+	}
+
+	if r.lineWriter != nil {
+		if msg := r.completionMessage(); msg != nil && r.lastReport != nil {
+			r.lineWriter.print(msg(*r.lastReport))
+		}
+		if r.hasLineSlot {
+			// the next report takes a new slot
+			r.lineWriter.release(r.lineSlot)
+			r.hasLineSlot = false
+		}
+		if r.bellOnComplete && r.complete {
+			r.lineWriter.bell()
+		}
+		return
+	}
+
+	if r.writer == nil {
+		// nothing was rendered
+		if msg := r.completionMessage(); msg != nil && r.lastReport != nil {
+			_, _ = io.WriteString(r.output, msg(*r.lastReport)+"\n")
+		}
+		return
+	}
+
+	if r.stopResize != nil {
+		r.stopResize()
+		r.stopResize = nil
+	}
+
+	if r.sticky {
+		r.releaseStickyBottom()
+		r.writeString(r.lastLegend)
+	}
+	if r.screen {
+		r.writeString(exitScreenSequence)
+		r.screen = false
+		r.writeString(r.lastLegend)
+	}
+
+	// log lines are finished already
+	if !strings.HasSuffix(r.lastLegend, "\n") {
+		r.writeString("\n")
+	}
+	if msg := r.completionMessage(); msg != nil && r.lastReport != nil {
+		r.writeString(msg(*r.lastReport) + "\n")
+	}
+	if r.bellOnComplete && r.complete && r.tty {
+		r.writeString("\a")
+	}
 	r.flush()
 }
 
+// refresh compiles legend and updates terminal size
+func (r *TextReporter) refresh() {
+	r.legendCompiled = r.compileLegend(r.legend)
+	r.termWidth = 0
+	if r.refreshOnResize {
+		if width, ok := terminalWidth(r.output); ok {
+			r.termWidth = width
+		}
+	}
+	r.lineWidth = 0
+	if r.messageTruncate != TruncateNone && r.messageWidth == 0 {
+		if width, ok := terminalWidth(r.output); ok {
+			r.lineWidth = width
+		}
+	}
+
+	if r.sticky {
+		r.reserveStickyBottom()
+	}
+}
+
+// reserveStickyBottom limits the scrolling region of the terminal to all lines but the last one
+func (r *TextReporter) reserveStickyBottom() {
+	_, height, ok := terminalSize(r.output)
+	if !ok || height < 2 {
+		return
+	}
+
+	if r.termHeight == 0 {
+		// make sure the cursor is not on the last line before it is reserved
+		r.writeString("\n\x1b[1A")
+	}
+	r.termHeight = height
+
+	// save cursor, set scrolling region, restore cursor
+	r.writeString("\x1b7\x1b[1;" + strconv.Itoa(height-1) + "r\x1b8")
+}
+
+// releaseStickyBottom clears the last line and restores the scrolling region of the terminal
+func (r *TextReporter) releaseStickyBottom() {
+	if r.termHeight == 0 {
+		return
+	}
+
+	r.writeString("\x1b7\x1b[" + strconv.Itoa(r.termHeight) + ";1H\x1b[2K\x1b[r\x1b8")
+	r.termHeight = 0
+}
+
+// writeSticky writes the legend on the last line of the terminal keeping the cursor in place
+func (r *TextReporter) writeSticky(legend string) {
+	if r.termHeight == 0 {
+		r.writeString(legend)
+		return
+	}
+
+	r.writeString("\x1b7\x1b[" + strconv.Itoa(r.termHeight) + ";1H\x1b[2K")
+	r.writeString(legend)
+	r.writeString("\x1b8")
+}
+
+// enterScreenSequence switches to the alternate screen, clears it and moves the cursor home
+const enterScreenSequence = "\x1b[?1049h\x1b[2J\x1b[H"
+
+// exitScreenSequence switches back to the main screen, which restores its content
+const exitScreenSequence = "\x1b[?1049l"
+
+// writeCentered clears the screen and writes the line in its center
+func (r *TextReporter) writeCentered(line string) {
+	r.writeString("\x1b[2J")
+	width, height, ok := terminalSize(r.output)
+	if !ok {
+		r.writeString("\x1b[H" + line)
+		return
+	}
+
+	row := (height + 1) / 2
+	col := 1
+	if lineWidth := displayWidth(line); lineWidth < width {
+		col += (width - lineWidth) / 2
+	}
+	r.writeString("\x1b[" + strconv.Itoa(row) + ";" + strconv.Itoa(col) + "H" + line)
+}
+
+// legendPlaceholders maps legend placeholders to format specifiers of corresponding Report() arguments
+var legendPlaceholders = []struct {
+	placeholder string
+	spec        string
+}{
+	{"{now}", "%[1]s"},
+	{"{started_at}", "%[2]s"},
+	{"{dt}", "%[3]s"},
+	{"{total}", "%[4]v"},
+	{"{done}", "%[5]v"},
+	{"{left}", "%[6]v"},
+	{"{ratio}", "%.{float_precision}[7]f"},
+	{"{percent_int}", "%[8]d"},
+	{"{percent_float}", "%.{float_precision}[9]f"},
+	{"{elapsed}", "%[10]s"},
+	{"{eta}", "%[11]s"},
+	{"{rps_avg}", "%.{float_precision}[12]f"},
+	{"{rps_inst}", "%.{float_precision}[13]f"},
+	{"{rpm}", "%.{float_precision}[14]f"},
+
+	{"{progress_bar}", "%[15]s"},
+	{"{done_marker}", "%[16]s"},
+	{"{done_bytes}", "%[17]s"},
+	{"{total_bytes}", "%[18]s"},
+	{"{rps_bytes}", "%[19]s"},
+	{"{phase}", "%[20]s"},
+	{"{finish_at}", "%[21]s"},
+	{"{rps_stddev}", "%.{float_precision}[22]f"},
+	{"{elapsed_of_total}", "%[23]s"},
+	{"{queue}", "%[24]d"},
+	{"{eta_compact}", "%[25]s"},
+	{"{elapsed_compact}", "%[26]s"},
+	{"{speed}", "%[27]s"},
+	{"{rps_per_worker}", "%.{float_precision}[28]f"},
+	{"{eta_fuzzy}", "%[29]s"},
+	{"{errors}", "%[30]d"},
+	{"{deadline_risk}", "%[31]s"},
+	{"{message}", "%[32]s"},
+	{"{left_bytes}", "%[33]s"},
+	{"{label}", "%[34]s"},
+	{"{percent_rate}", "%.{float_precision}[35]f"},
+	{"{avg_item}", "%[36]s"},
+	{"{elapsed_active}", "%[37]s"},
+	{"{rps_scaled}", rpsScaledSpec},
+	{"{seq}", "%[39]d"},
+}
+
+// rpsScaledSpec is the format specifier of {rps_scaled}, see WithRPSScaled
+const rpsScaledSpec = "%[38]s"
+
+// messageArg is the index of {message} argument of the compiled legend
+const messageArg = 31
+
+// progressBarArg is the index of {progress_bar} argument of the compiled legend
+const progressBarArg = 14
+
+// legendTokenRe matches anything looking like a placeholder
+var legendTokenRe = regexp.MustCompile(`\{[^{}\s]*\}`)
+
+// ValidateLegend checks that the legend contains only known placeholders.
+// Unknown placeholders are rendered literally, so the returned error lists them.
+func (r *TextReporter) ValidateLegend() error {
+	var unknown []string
+	for _, token := range legendTokenRe.FindAllString(r.legend, -1) {
+		if !isLegendPlaceholder(token) {
+			unknown = append(unknown, token)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown legend placeholders: %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// isLegendPlaceholder reports whether given token is a known placeholder
+func isLegendPlaceholder(token string) bool {
+	for _, p := range legendPlaceholders {
+		if p.placeholder == token {
+			return true
+		}
+	}
+	return false
+}
+
+// compiledLegend returns format split into segments. The last compiled format is cached
+func (r *TextReporter) compiledLegend(format string) *compiledLegend {
+	if r.segments == nil || r.segments.format != format {
+		r.segments = compileSegments(format)
+	}
+	return r.segments
+}
+
 // compileLegend replaces placeholders with corresponding format specifiers
-func (r *TextReporter) compileLegend(format string, floatPrecision int) string {
-	format = strings.ReplaceAll(format, "{now}", "%[1]s")
-	format = strings.ReplaceAll(format, "{started_at}", "%[2]s")
-	format = strings.ReplaceAll(format, "{dt}", "%[3]s")
-	format = strings.ReplaceAll(format, "{total}", "%[4]d")
-	format = strings.ReplaceAll(format, "{done}", "%[5]d")
-	format = strings.ReplaceAll(format, "{left}", "%[6]d")
-	format = strings.ReplaceAll(format, "{ratio}", "%.{float_precision}[7]f")
-	format = strings.ReplaceAll(format, "{percent_int}", "%[8]d")
-	format = strings.ReplaceAll(format, "{percent_float}", "%.{float_precision}[9]f")
-	format = strings.ReplaceAll(format, "{elapsed}", "%[10]s")
-	format = strings.ReplaceAll(format, "{eta}", "%[11]s")
-	format = strings.ReplaceAll(format, "{rps_avg}", "%.{float_precision}[12]f")
-	format = strings.ReplaceAll(format, "{rps_inst}", "%.{float_precision}[13]f")
-	format = strings.ReplaceAll(format, "{rpm}", "%.{float_precision}[14]f")
-
-	format = strings.ReplaceAll(format, "{progress_bar}", "%[15]s")
-
-	format = strings.ReplaceAll(format, "{float_precision}", strconv.Itoa(floatPrecision))
+func (r *TextReporter) compileLegend(format string) string {
+	for _, p := range legendPlaceholders {
+		precision, ok := r.precisions[p.placeholder]
+		if !ok {
+			precision = r.floatPrecision
+		}
+		spec := p.spec
+		if r.rpsScaled && p.placeholder == "{rps_avg}" {
+			spec = rpsScaledSpec
+		}
+		spec = strings.ReplaceAll(spec, "{float_precision}", strconv.Itoa(precision))
+		if a, ok := r.aligns[p.placeholder]; ok && a.width > 0 {
+			flags := strconv.Itoa(a.width)
+			if a.align == AlignLeft {
+				flags = "-" + flags
+			}
+			spec = "%" + flags + spec[1:]
+		}
+		format = strings.ReplaceAll(format, p.placeholder, spec)
+	}
+
 	return format
 }
 
-// renderProgressBar builds and returns string containing progress bar
-func (r *TextReporter) renderProgressBar(report Report) string {
+// progressBarMinCells is the minimal number of cells the progress bar is drawn with
+const progressBarMinCells = 3
+
+// renderProgressBar builds and returns string containing progress bar of given width. When the
+// bar does not fit into its width, percentage is returned instead
+func (r *TextReporter) renderProgressBar(report Report, pbWidth int) string {
 	ratio := report.Ratio
 	if ratio < 0 {
 		ratio = 0
 	}
-	progressBarWidth := r.pbWidth - 2 // [ and ]
-	if progressBarWidth <= 0 {
-		return ""
+	if r.animatedFill {
+		ratio = r.animateRatio(ratio, report.IsComplete)
+	}
+	style := r.barStyle
+	progressBarWidth := pbWidth - displayWidth(style.Left) - displayWidth(style.Right)
+	if progressBarWidth < progressBarMinCells {
+		// too narrow for a bar, fall back to a percentage. It does not exceed the width either, so
+		// the sign and then the number are dropped when they do not fit
+		percent := int(ratio * 100)
+		if percent > 100 {
+			percent = 100
+		}
+		digits := strconv.Itoa(percent)
+		switch {
+		case len(digits)+1 <= pbWidth:
+			return digits + "%"
+		case len(digits) <= pbWidth:
+			return digits
+		default:
+			return ""
+		}
 	}
 
-	fillChars := int(ratio * float64(progressBarWidth))
-	if fillChars > progressBarWidth {
-		fillChars = progressBarWidth
+	if r.renderFunc != nil {
+		var sb strings.Builder
+		sb.WriteString(style.Left)
+		for i := 0; i < progressBarWidth; i++ {
+			sb.WriteRune(r.renderFunc(i, progressBarWidth, ratio))
+		}
+		sb.WriteString(style.Right)
+		return sb.String()
 	}
 
-	fillSpaces := progressBarWidth - fillChars
+	// wide cell characters take several columns, so the bar has fewer cells
+	cellWidth := style.cellWidth()
+	cells := progressBarWidth / cellWidth
+	padding := progressBarWidth - cells*cellWidth
+
+	fillChars := int(ratio * float64(cells))
+	if fillChars > cells {
+		fillChars = cells
+	}
+
+	fillSpaces := cells - fillChars
 	if fillSpaces < 0 {
 		fillSpaces = 0
 	}
 
-	progressBar := "["
-	progressBar += strings.Repeat("#", fillChars)
-	progressBar += strings.Repeat("-", fillSpaces)
-	progressBar += "]"
+	gradient := r.gradient != nil && r.colorMode != colorModeNone
+	errorColor := r.errorColor != nil && r.colorMode != colorModeNone
+
+	// failed items take the end of the filled region proportionally to their share in done items
+	headCell := -1
+	if style.Head != "" && fillChars < cells {
+		headCell = fillChars - 1
+	}
+	errorEnd := fillChars
+	if headCell >= 0 {
+		errorEnd = headCell
+	}
+	errorStart := errorEnd - r.errorCells(report, fillChars)
+	if errorStart < 0 {
+		errorStart = 0
+	}
+
+	var sb strings.Builder
+	sb.WriteString(style.Left)
+	for i := 0; i < fillChars; i++ {
+		isError := i >= errorStart && i < errorEnd
+		if errorColor && isError {
+			sb.WriteString(foregroundSequence(*r.errorColor, r.colorMode))
+		} else if gradient {
+			t := 0.0
+			if cells > 1 {
+				t = float64(i) / float64(cells-1)
+			}
+			sb.WriteString(foregroundSequence(interpolateColor(r.gradient[0], r.gradient[1], t), r.colorMode))
+		} else if errorColor && i == errorEnd && errorStart < errorEnd {
+			sb.WriteString(resetSequence)
+		}
 
-	return progressBar
+		switch {
+		case i == headCell:
+			sb.WriteString(style.Head)
+		case isError && style.Error != "":
+			sb.WriteString(style.Error)
+		default:
+			sb.WriteString(style.Fill)
+		}
+	}
+	if (gradient || errorColor && errorStart < errorEnd) && fillChars > 0 {
+		sb.WriteString(resetSequence)
+	}
+	secondaryCells := 0
+	if style.Secondary != "" {
+		secondaryCells = int(report.SecondaryRatio*float64(cells)) - fillChars
+		if secondaryCells > fillSpaces {
+			secondaryCells = fillSpaces
+		}
+		if secondaryCells < 0 {
+			secondaryCells = 0
+		}
+	}
+	sb.WriteString(strings.Repeat(style.Secondary, secondaryCells))
+	if len(r.tickMarks) == 0 {
+		sb.WriteString(strings.Repeat(style.Empty, fillSpaces-secondaryCells))
+	} else {
+		tick := string(r.tickChar)
+		if tickWidth := displayWidth(tick); tickWidth < cellWidth {
+			tick += strings.Repeat(" ", cellWidth-tickWidth)
+		}
+		for i := fillChars + secondaryCells; i < cells; i++ {
+			if r.isTickCell(i, cells) {
+				sb.WriteString(tick)
+			} else {
+				sb.WriteString(style.Empty)
+			}
+		}
+	}
+	sb.WriteString(strings.Repeat(" ", padding))
+	sb.WriteString(style.Right)
+
+	return sb.String()
+}
+
+// animatedFillEasing is the part of the distance to the actual ratio the animated fill moves by
+// on each render
+const animatedFillEasing = 0.5
+
+// isTickCell reports whether the cell of the progress bar with given number of cells has a tick mark
+func (r *TextReporter) isTickCell(cell, cells int) bool {
+	for _, position := range r.tickMarks {
+		if position >= 0 && position < 1 && int(position*float64(cells)) == cell {
+			return true
+		}
+	}
+	return false
+}
+
+// animateRatio moves the displayed ratio towards the actual one and returns it. The displayed
+// ratio never exceeds the actual one and is complete as soon as the work is
+func (r *TextReporter) animateRatio(ratio float64, complete bool) float64 {
+	switch {
+	case complete || ratio <= r.displayedRatio:
+		r.displayedRatio = ratio
+	case ratio-r.displayedRatio < 0.001:
+		r.displayedRatio = ratio
+	default:
+		r.displayedRatio += (ratio - r.displayedRatio) * animatedFillEasing
+	}
+	return r.displayedRatio
+}
+
+// errorCells returns the number of filled cells representing failed items. Any failed item takes
+// at least one cell. Zero when neither error character nor error color is set
+func (r *TextReporter) errorCells(report Report, fillChars int) int {
+	if r.barStyle.Error == "" && r.errorColor == nil || report.Errors <= 0 || report.Done64 <= 0 || fillChars == 0 {
+		return 0
+	}
+
+	cells := int(math.Round(float64(fillChars) * float64(report.Errors) / float64(report.Done64)))
+	if cells < 1 {
+		cells = 1
+	}
+	if cells > fillChars {
+		cells = fillChars
+	}
+	return cells
+}
+
+// renderDoneMarker returns check mark for complete progress and the next spinner frame otherwise
+func (r *TextReporter) renderDoneMarker(report Report) string {
+	if report.IsComplete {
+		return TextReporterDoneMarker
+	}
+
+	if r.spinnerInterval > 0 {
+		frame := int(report.Elapsed / r.spinnerInterval)
+		return r.spinnerFrames[frame%len(r.spinnerFrames)]
+	}
+
+	frame := r.spinnerFrames[r.spinnerFrame%len(r.spinnerFrames)]
+	r.spinnerFrame++
+	return frame
+}
+
+// roundShortDuration rounds d to seconds, keeping tenths of a second under 10 seconds
+// and hundredths under a second
+func roundShortDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= 10*time.Second:
+		return d.Round(time.Second)
+	case d >= time.Second:
+		return d.Round(100 * time.Millisecond)
+	default:
+		return d.Round(10 * time.Millisecond)
+	}
+}
+
+// roundETA rounds d to seconds under a minute, to 5 seconds under an hour and to minutes above,
+// so long ETAs do not flicker
+func roundETA(d time.Duration) time.Duration {
+	switch {
+	case d < time.Minute:
+		return d.Round(time.Second)
+	case d < time.Hour:
+		return d.Round(5 * time.Second)
+	default:
+		return d.Round(time.Minute)
+	}
+}
+
+// skipLogLine reports whether the report should not be rendered to non-terminal output
+// according to WithLogEvery and WithLogEveryPercent
+func (r *TextReporter) skipLogLine(report Report) bool {
+	if r.tty {
+		return false
+	}
+
+	index := r.reportIndex
+	r.reportIndex++
+	if index == 0 {
+		r.loggedPercent = report.PercentFloat
+		return false
+	}
+
+	if r.logEvery > 1 && index%r.logEvery != 0 {
+		return true
+	}
+
+	if r.logEveryPercent > 0 {
+		if report.PercentFloat-r.loggedPercent < r.logEveryPercent {
+			return true
+		}
+		r.loggedPercent = report.PercentFloat
+	}
+
+	return false
+}
+
+// alignColumns pads tab separated columns of the rendered legend to their widest seen width
+func (r *TextReporter) alignColumns(legend string) string {
+	line := strings.TrimRight(legend, "\r\n")
+	ending := legend[len(line):]
+	columns := strings.Split(line, "\t")
+
+	var sb strings.Builder
+	for i, column := range columns {
+		if i == len(columns)-1 {
+			sb.WriteString(column)
+			break
+		}
+
+		width := displayWidth(column)
+		if i >= len(r.columnWidths) {
+			r.columnWidths = append(r.columnWidths, width)
+		} else if width > r.columnWidths[i] {
+			r.columnWidths[i] = width
+		}
+
+		sb.WriteString(column)
+		sb.WriteString(strings.Repeat(" ", r.columnWidths[i]-width))
+		sb.WriteString(" ")
+	}
+	sb.WriteString(ending)
+
+	return sb.String()
+}
+
+// quantity returns value of a counter to be rendered according to the report unit
+func (r *TextReporter) quantity(report Report, n int64) any {
+	if report.Unit == UnitDuration {
+		return time.Duration(n).Round(time.Second)
+	}
+	return n
+}
+
+// renderSpeed renders average rate in configured units
+func (r *TextReporter) renderSpeed(report Report) string {
+	if r.hideSpeed {
+		return ""
+	}
+
+	if r.speedUnit == SpeedUnitBytes || r.byteMode || report.Unit == UnitBytes {
+		return r.formatBytes(report.RPSAvg) + "/s"
+	}
+	return FormatRate(report.RPSAvg, "items")
+}
+
+// formatBytes formats number of bytes using configured units
+func (r *TextReporter) formatBytes(n float64) string {
+	if r.siUnits {
+		return formatBytes(n, 1000, siUnits)
+	}
+	return formatBytes(n, 1024, iecUnits)
 }
 
 // writeString writes given string to the output. it just proxies WriteString
@@ -245,7 +1670,21 @@ func (r *TextReporter) flush() {
 	_ = r.writer.Flush()
 }
 
+// lockedWriter serializes writes to the underlying writer with a mutex
+type lockedWriter struct {
+	w  io.Writer
+	mu *sync.Mutex
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
 func (r *TextReporter) clone() *TextReporter {
 	cp := *r
+	cp.mu = &sync.Mutex{}
+	cp.textReporterState = textReporterState{}
 	return &cp
 }
@@ -0,0 +1,877 @@
+package gopv
+
+import (
+	"bytes"
+	"image/color"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestByteModeETA(t *testing.T) {
+	const mib = 1 << 20
+	tests := []struct {
+		done    int64
+		elapsed time.Duration
+		want    string
+	}{
+		{done: 10 * mib, elapsed: time.Second, want: "10.0 MiB/s, ETA 9s"},
+		{done: 40 * mib, elapsed: 4 * time.Second, want: "10.0 MiB/s, ETA 6s"},
+		{done: 96 * mib, elapsed: 9600 * time.Millisecond, want: "10.0 MiB/s, ETA 400ms"},
+		{done: 99 * mib, elapsed: 9900 * time.Millisecond, want: "10.0 MiB/s, ETA 100ms"},
+	}
+
+	r := NewTextReporter().WithByteMode(true).WithLegend("{rps_bytes}, ETA {eta}")
+	for _, tt := range tests {
+		report := NewReport(WithDone(tt.done), WithTotal(100*mib), WithElapsed(tt.elapsed), WithReportUnit(UnitBytes))
+		if got := r.RenderString(report); got != tt.want {
+			t.Errorf("done %d MiB: got %q, want %q", tt.done/mib, got, tt.want)
+		}
+	}
+}
+
+// sampleReport returns a report of 30 of 120 items done in 10 seconds
+func sampleReport() Report {
+	return NewReport(
+		WithNow(time.Date(2023, 12, 2, 8, 52, 24, 0, time.UTC)),
+		WithDone(30),
+		WithTotal(120),
+		WithElapsed(10*time.Second),
+	)
+}
+
+func TestRenderString(t *testing.T) {
+	r := NewTextReporter()
+	want := "[2023-12-02 08:52:24] - working (30/120) done 25%, RPS 3.00, elapsed 10s, ETA 30s"
+	if got := r.RenderString(sampleReport()); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderStringDoesNotChangeReporter(t *testing.T) {
+	r := NewTextReporter().WithLegend("{done_marker}\t{done}\t{percent_int}")
+	report := sampleReport()
+	first := r.RenderString(report)
+	for i := 0; i < 3; i++ {
+		if got := r.RenderString(report); got != first {
+			t.Fatalf("render %d: got %q, want %q", i, got, first)
+		}
+	}
+	if r.spinnerFrame != 0 || r.buf != nil || r.args != nil || r.segments != nil {
+		t.Fatal("RenderString changed runtime state of the reporter")
+	}
+}
+
+func TestRenderStringConcurrentWithReport(t *testing.T) {
+	var out bytes.Buffer
+	r := NewTextReporter().WithOutput(&out).WithLegend("{progress_bar} {done_marker}\r").WithAnimatedFill(true)
+	report := sampleReport()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			r.Report(report)
+		}
+		r.Finalize()
+	}()
+	for i := 0; i < 1000; i++ {
+		_ = r.RenderString(report)
+	}
+	<-done
+}
+
+// barReport returns a report of done items of 100 with given failed and secondary items
+func barReport(done, errors, secondary int64) Report {
+	report := NewReport(WithDone(done), WithErrors(errors), WithTotal(100))
+	report.Secondary = secondary
+	report.SecondaryRatio = float64(secondary) / 100
+	return report
+}
+
+func TestProgressBarErrorRegion(t *testing.T) {
+	// 30 done items of 100 take 12 of 40 cells, 10 of them failed take a third of those
+	r := NewTextReporter().WithProgressBarWidth(42).WithLegend("{progress_bar}").WithBarErrorChar("!")
+	want := "[########!!!!----------------------------]"
+	if got := r.RenderString(barReport(30, 10, 0)); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestProgressBarErrorRegionIsOptIn(t *testing.T) {
+	r := NewTextReporter().WithProgressBarWidth(42).WithLegend("{progress_bar}")
+	want := "[############----------------------------]"
+	if got := r.RenderString(barReport(30, 10, 0)); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestProgressBarSecondaryRegion(t *testing.T) {
+	// 30 done items of 100 take 12 of 40 cells, the secondary position of 60 takes 12 more
+	r := NewTextReporter().WithProgressBarWidth(42).WithLegend("{progress_bar}")
+	want := "[############============----------------]"
+	if got := r.RenderString(barReport(30, 0, 60)); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// secondary position behind done items is covered by them
+	want = "[########################----------------]"
+	if got := r.RenderString(barReport(60, 0, 30)); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	pv := New(100).WithReporter(NewNullReporter())
+	pv.Add(30)
+	pv.SetSecondary(60)
+	if report := pv.Snapshot(); report.Secondary != 60 || report.SecondaryRatio != 0.6 {
+		t.Fatalf("secondary %d, ratio %v, want 60 and 0.6", report.Secondary, report.SecondaryRatio)
+	}
+}
+
+func TestMessageTruncateWideCharacters(t *testing.T) {
+	r := NewTextReporter().WithLegend("[{message}]").WithMessageTruncate(TruncateEnd, 10)
+	report := NewReport(WithMessage("文件名字很长的文件.txt"))
+	if got, want := r.RenderString(report), "[文件名...]"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestAnimatedFill(t *testing.T) {
+	r := NewTextReporter().WithOutput(&bytes.Buffer{}).WithProgressBarWidth(22).
+		WithLegend("{progress_bar}\n").WithAnimatedFill(true)
+	fill := func(report Report) int {
+		r.Report(report)
+		return strings.Count(r.lastLegend, "#")
+	}
+
+	// the actual progress jumps to 10 of 20 cells at once
+	prev := fill(barReport(0, 0, 0))
+	for i := 0; i < 10; i++ {
+		got := fill(barReport(50, 0, 0))
+		if got > 10 {
+			t.Fatalf("tick %d: fill %d is ahead of the actual progress", i, got)
+		}
+		if got < prev {
+			t.Fatalf("tick %d: fill went back from %d to %d", i, prev, got)
+		}
+		if i == 0 && got == 10 {
+			t.Fatalf("fill does not lag behind the actual progress")
+		}
+		prev = got
+	}
+	if prev != 10 {
+		t.Fatalf("fill %d does not converge to 10", prev)
+	}
+
+	if got := fill(barReport(60, 0, 0)); got >= 12 {
+		t.Errorf("fill %d does not lag behind 12", got)
+	}
+	if got := fill(barReport(100, 0, 0)); got != 20 {
+		t.Errorf("fill %d is not full on completion", got)
+	}
+}
+
+func TestCloneResetsState(t *testing.T) {
+	var out bytes.Buffer
+	r := NewTextReporter().WithOutput(&out).WithLegend("{progress_bar}\t{message}\n").
+		WithAnimatedFill(true).WithTabularAlignment(true).WithMessageTruncate(TruncateEnd, 10)
+	report := barReport(50, 0, 0)
+	report.Message = "message"
+	r.Report(report)
+	r.Report(barReport(100, 0, 0))
+	if reflect.DeepEqual(r.textReporterState, textReporterState{}) {
+		t.Fatal("reporter has no state after rendering")
+	}
+
+	cp := r.WithLegend("{progress_bar}\n")
+	if !reflect.DeepEqual(cp.textReporterState, textReporterState{}) {
+		t.Errorf("clone has state of the original reporter: %+v", cp.textReporterState)
+	}
+	if cp.mu == r.mu {
+		t.Error("clone shares the mutex of the original reporter")
+	}
+
+	// the clone starts from an empty bar, not from the fill displayed by the original
+	cp.Report(barReport(50, 0, 0))
+	if fill := strings.Count(cp.lastLegend, "#"); fill >= 40 {
+		t.Errorf("clone fill %d does not start from zero", fill)
+	}
+}
+
+func TestSIUnits(t *testing.T) {
+	report := NewReport(WithDone(1000), WithTotal(1024), WithElapsed(time.Second))
+	r := NewTextReporter().WithLegend("{done_bytes}/{total_bytes}, {rps_bytes}")
+	if got, want := r.RenderString(report), "1000 B/1.0 KiB, 1000 B/s"; got != want {
+		t.Errorf("IEC: got %q, want %q", got, want)
+	}
+	if got, want := r.WithSIUnits(true).RenderString(report), "1.0 kB/1.0 kB, 1.0 kB/s"; got != want {
+		t.Errorf("SI: got %q, want %q", got, want)
+	}
+}
+
+func TestNowFunc(t *testing.T) {
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := NewTextReporter().WithLegend("{now} {started_at}").
+		WithNowFunc(func() time.Time { return fixed })
+
+	// the report is made at the real time, the rendered timestamps are shifted to the fixed one
+	report := NewReport(WithNow(time.Now()), WithElapsed(10*time.Second))
+	if got, want := r.RenderString(report), "2024-01-02 03:04:05 2024-01-02 03:03:55"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestValidateLegend(t *testing.T) {
+	r := NewTextReporter().WithLegend("{persent_int}% of {total}, {eta} {rsp_avg}")
+	err := r.ValidateLegend()
+	if err == nil || err.Error() != "unknown legend placeholders: {persent_int}, {rsp_avg}" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := NewTextReporter().ValidateLegend(); err != nil {
+		t.Fatalf("default legend is not valid: %v", err)
+	}
+}
+
+func TestStrictLegend(t *testing.T) {
+	r := NewTextReporter().WithOutput(&bytes.Buffer{}).WithLegend("{persent_int}").WithStrictLegend(true)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("strict reporter does not panic on unknown placeholder")
+		}
+	}()
+	r.Report(sampleReport())
+}
+
+func TestGradient(t *testing.T) {
+	blue, green := color.RGBA{B: 255, A: 255}, color.RGBA{G: 255, A: 255}
+	r := NewTextReporter().WithLegend("{progress_bar}").WithProgressBarWidth(12).WithGradient(blue, green)
+
+	tests := []struct {
+		mode        colorMode
+		first, last string
+	}{
+		{mode: colorModeTrueColor, first: "\x1b[38;2;0;0;255m", last: "\x1b[38;2;0;255;0m"},
+		{mode: colorMode256, first: "\x1b[38;5;21m", last: "\x1b[38;5;46m"},
+	}
+	for _, tt := range tests {
+		r.colorMode = tt.mode
+		got := r.RenderString(barReport(100, 0, 0))
+		cells := strings.Split(strings.TrimSuffix(strings.TrimPrefix(got, "["), resetSequence+"]"), "#")
+		if len(cells) != 11 {
+			t.Fatalf("mode %d: unexpected bar %q", tt.mode, got)
+		}
+		if cells[0] != tt.first || cells[9] != tt.last {
+			t.Errorf("mode %d: first cell %q, last cell %q, want %q and %q", tt.mode, cells[0], cells[9], tt.first, tt.last)
+		}
+	}
+
+	// output without colors gets a plain bar
+	r.colorMode = colorModeNone
+	if got, want := r.RenderString(barReport(100, 0, 0)), "[##########]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMinLineWidth(t *testing.T) {
+	var out bytes.Buffer
+	r := NewTextReporter().WithOutput(&out).WithLegend("{done}/{total}\n").WithMinLineWidth(10)
+	for _, done := range []int64{5, 500, 12345678, 7} {
+		r.Report(NewReport(WithDone(done), WithTotal(1000)))
+	}
+	r.Finalize()
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	// short lines are padded to 10 columns, the line after a longer one is padded over it
+	want := []string{"5/1000    ", "500/1000  ", "12345678/1000", "7/1000       "}
+	if len(lines) != len(want) {
+		t.Fatalf("got %q", out.String())
+	}
+	for i, line := range lines {
+		if line != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, line, want[i])
+		}
+	}
+
+	out.Reset()
+	r = NewTextReporter().WithOutput(&out).WithLegend("{done}\r").WithMinLineWidth(10).WithForceTTY(true)
+	for _, done := range []int64{5, 500, 12345678901, 7} {
+		r.Report(NewReport(WithDone(done), WithTotal(100000000000)))
+	}
+	for i, line := range strings.Split(strings.TrimSuffix(out.String(), "\r"), "\r") {
+		if displayWidth(line) < 10 {
+			t.Errorf("line %d %q is shorter than 10 columns", i, line)
+		}
+	}
+}
+
+func TestFinishAt(t *testing.T) {
+	now := time.Date(2023, 12, 2, 8, 52, 24, 0, time.UTC)
+	r := NewTextReporter().WithLegend("done ~{finish_at}")
+
+	report := NewReport(WithNow(now), WithDone(30), WithTotal(120), WithETA(time.Hour+12*time.Minute+48*time.Second))
+	if want := now.Add(time.Hour + 12*time.Minute + 48*time.Second); !report.FinishAt.Equal(want) {
+		t.Fatalf("FinishAt = %v, want %v", report.FinishAt, want)
+	}
+	if got, want := r.RenderString(report), "done ~2023-12-02 10:05:12"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	report = NewReport(WithNow(now), WithDone(0), WithTotal(120))
+	if got, want := r.RenderString(report), "done ~unknown"; got != want {
+		t.Errorf("unknown ETA: got %q, want %q", got, want)
+	}
+}
+
+func TestQuietUntil(t *testing.T) {
+	run := func(elapsed ...time.Duration) string {
+		var out bytes.Buffer
+		r := NewTextReporter().WithOutput(&out).WithLegend("{done}/{total}\n").WithQuietUntil(time.Second)
+		for i, e := range elapsed {
+			r.Report(NewReport(WithDone(int64(i+1)), WithTotal(int64(len(elapsed))), WithElapsed(e)))
+		}
+		r.Finalize()
+		return out.String()
+	}
+
+	if got := run(100*time.Millisecond, 500*time.Millisecond); got != "" {
+		t.Errorf("fast run: got %q, want no output", got)
+	}
+	if got, want := run(500*time.Millisecond, 1500*time.Millisecond, 2*time.Second), "2/3\n3/3\n"; got != want {
+		t.Errorf("slow run: got %q, want %q", got, want)
+	}
+}
+
+func TestBarBrackets(t *testing.T) {
+	tests := []struct {
+		left, right string
+		want        string
+	}{
+		{left: "(", right: ")", want: "(#####-----)"},
+		{left: "", right: "", want: "######------"},
+		{left: "【", right: "】", want: "【####----】"},
+	}
+	for _, tt := range tests {
+		r := NewTextReporter().WithLegend("{progress_bar}").WithProgressBarWidth(12).WithBarBrackets(tt.left, tt.right)
+		got := r.RenderString(barReport(50, 0, 0))
+		if got != tt.want {
+			t.Errorf("brackets %q %q: got %q, want %q", tt.left, tt.right, got, tt.want)
+		}
+		if width := displayWidth(got); width != 12 {
+			t.Errorf("brackets %q %q: bar is %d columns wide, want 12", tt.left, tt.right, width)
+		}
+	}
+}
+
+// exclusiveWriter fails the test if writes overlap
+type exclusiveWriter struct {
+	t       *testing.T
+	writing int32
+	buf     bytes.Buffer
+}
+
+func (w *exclusiveWriter) Write(p []byte) (int, error) {
+	if !atomic.CompareAndSwapInt32(&w.writing, 0, 1) {
+		w.t.Error("concurrent writes")
+	}
+	defer atomic.StoreInt32(&w.writing, 0)
+	time.Sleep(10 * time.Microsecond)
+	return w.buf.Write(p)
+}
+
+func TestOutputSynced(t *testing.T) {
+	out := &exclusiveWriter{t: t}
+	var mu sync.Mutex
+	r := NewTextReporter().WithOutputSynced(out, &mu).WithLegend("{done}/{total}\n")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			r.Report(NewReport(WithDone(int64(i)), WithTotal(100)))
+		}
+		r.Finalize()
+	}()
+	go func() {
+		defer wg.Done()
+		// the application serializes its own writes with the same mutex
+		for i := 0; i < 100; i++ {
+			mu.Lock()
+			_, _ = out.Write([]byte("log line\n"))
+			mu.Unlock()
+		}
+	}()
+	wg.Wait()
+
+	if got := strings.Count(out.buf.String(), "log line\n"); got != 100 {
+		t.Fatalf("%d log lines are intact, want 100", got)
+	}
+}
+
+func TestElapsedOfTotal(t *testing.T) {
+	r := NewTextReporter().WithLegend("{elapsed_of_total}")
+	report := NewReport(WithDone(4), WithTotal(32), WithElapsed(4*time.Second), WithETA(28*time.Second))
+	if got, want := r.RenderString(report), "4s / ~32s"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	report = NewReport(WithDone(0), WithTotal(32), WithElapsed(4*time.Second))
+	if got, want := r.RenderString(report), "4s / unknown"; got != want {
+		t.Errorf("unknown ETA: got %q, want %q", got, want)
+	}
+}
+
+func TestMixedPrecisions(t *testing.T) {
+	// 1 of 3 items in 3 seconds
+	report := NewReport(WithDone(1), WithTotal(3), WithElapsed(3*time.Second))
+	r := NewTextReporter().WithLegend("{percent_float}%% {rps_avg} {ratio}").
+		WithFloatPrecision(3).WithPercentDecimals(1).WithRPSDecimals(2)
+	if got, want := r.RenderString(report), "33.3% 0.33 0.333"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	r = r.WithPlaceholderPrecision("{ratio}", 0).WithPercentDecimals(0)
+	if got, want := r.RenderString(report), "33% 0.33 0"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSpeed(t *testing.T) {
+	items := NewReport(WithDone(12000), WithTotal(100000), WithElapsed(10*time.Second))
+	bytesReport := NewReport(WithDone(120<<20), WithTotal(1<<30), WithElapsed(10*time.Second), WithReportUnit(UnitBytes))
+	r := NewTextReporter().WithLegend("{speed}")
+
+	tests := []struct {
+		r      *TextReporter
+		report Report
+		want   string
+	}{
+		{r: r, report: items, want: "1.2k items/s"},
+		{r: r, report: bytesReport, want: "12.0 MiB/s"},
+		{r: r.WithSpeedUnit(SpeedUnitBytes), report: items, want: "1.2 KiB/s"},
+		{r: r.WithShowSpeed(false), report: items, want: ""},
+	}
+	for i, tt := range tests {
+		if got := tt.r.RenderString(tt.report); got != tt.want {
+			t.Errorf("case %d: got %q, want %q", i, got, tt.want)
+		}
+	}
+}
+
+func TestTabularAlignment(t *testing.T) {
+	var out bytes.Buffer
+	r := NewTextReporter().WithOutput(&out).WithTabularAlignment(true).WithLegend("{done}/{total}\t{percent_int}%%\tETA {eta}\n")
+	reports := []Report{
+		NewReport(WithDone(5), WithTotal(100), WithElapsed(time.Second)),
+		NewReport(WithDone(50), WithTotal(100), WithElapsed(time.Second)),
+		NewReport(WithDone(100), WithTotal(100), WithElapsed(time.Second)),
+		NewReport(WithDone(7), WithTotal(100), WithElapsed(time.Second)),
+	}
+	for _, report := range reports {
+		r.Report(report)
+	}
+
+	// columns never shrink, so the third column starts at the same position on every line
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	want := []string{
+		"5/100 5% ETA 19s",
+		"50/100 50% ETA 1s",
+		"100/100 100% ETA 0s",
+		"7/100   7%   ETA 13s",
+	}
+	for i, line := range lines {
+		if line != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, line, want[i])
+		}
+	}
+}
+
+func TestFuzzyETA(t *testing.T) {
+	r := NewTextReporter().WithLegend("{eta_fuzzy} left, {eta}")
+	report := NewReport(WithDone(1), WithTotal(100), WithETA(118*time.Second))
+	if got, want := r.RenderString(report), "about 2 minutes left, 1m58s"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := r.RenderString(NewReport(WithDone(0), WithTotal(100))), "unknown left, 0s"; got != want {
+		t.Errorf("unknown ETA: got %q, want %q", got, want)
+	}
+}
+
+func TestLogDecimation(t *testing.T) {
+	// a long run of 10000 reports on a non-terminal output
+	run := func(r *TextReporter) []string {
+		var out bytes.Buffer
+		r = r.WithOutput(&out).WithLegend("{done}/{total}\n")
+		for i := int64(1); i <= 10000; i++ {
+			r.Report(NewReport(WithDone(i), WithTotal(10000)))
+		}
+		r.Finalize()
+		return strings.Fields(out.String())
+	}
+
+	lines := run(NewTextReporter().WithLogEvery(100))
+	if len(lines) != 101 || lines[1] != "101/10000" || lines[100] != "10000/10000" {
+		t.Errorf("every 100th report: %d lines, ending with %q", len(lines), lines[len(lines)-1])
+	}
+
+	lines = run(NewTextReporter().WithLogEveryPercent(10))
+	if len(lines) != 11 || lines[1] != "1001/10000" || lines[10] != "10000/10000" {
+		t.Errorf("every 10%%: %d lines %q", len(lines), lines)
+	}
+}
+
+func TestNarrowProgressBar(t *testing.T) {
+	tests := []struct {
+		width int
+		done  int64
+		want  string
+	}{
+		{width: 1, done: 50, want: ""},
+		{width: 2, done: 50, want: "50"},
+		{width: 3, done: 50, want: "50%"},
+		{width: 4, done: 50, want: "50%"},
+		{width: 1, done: 100, want: ""},
+		{width: 2, done: 100, want: ""},
+		{width: 3, done: 100, want: "100"},
+		{width: 4, done: 100, want: "100%"},
+		{width: 5, done: 50, want: "[#--]"},
+		{width: 12, done: 50, want: "[#####-----]"},
+	}
+	for _, tt := range tests {
+		r := NewTextReporter().WithLegend("{progress_bar}").WithProgressBarWidth(tt.width)
+		got := r.RenderString(barReport(tt.done, 0, 0))
+		if got != tt.want {
+			t.Errorf("width %d at %d%%: got %q, want %q", tt.width, tt.done, got, tt.want)
+		}
+		if displayWidth(got) > tt.width {
+			t.Errorf("width %d at %d%%: %q is wider than the bar", tt.width, tt.done, got)
+		}
+	}
+}
+
+func TestRenderFunc(t *testing.T) {
+	var calls []int
+	r := NewTextReporter().WithLegend("{progress_bar}").WithProgressBarWidth(12).
+		WithRenderFunc(func(cell, total int, ratio float64) rune {
+			calls = append(calls, cell)
+			if total != 10 {
+				t.Fatalf("total = %d, want 10", total)
+			}
+			if float64(cell) < ratio*float64(total) {
+				return '>'
+			}
+			return ' '
+		})
+	if got, want := r.RenderString(barReport(50, 0, 0)), "[>>>>>     ]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if len(calls) != 10 || calls[0] != 0 || calls[9] != 9 {
+		t.Errorf("render func is called for cells %v", calls)
+	}
+}
+
+func TestBellOnComplete(t *testing.T) {
+	tests := []struct {
+		tty      bool
+		done     int64
+		wantBell bool
+	}{
+		{tty: true, done: 100, wantBell: true},
+		{tty: true, done: 50, wantBell: false},
+		{tty: false, done: 100, wantBell: false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		r := NewTextReporter().WithOutput(&out).WithForceTTY(tt.tty).WithBellOnComplete(true).WithLegend("{done}\r")
+		r.Report(barReport(tt.done, 0, 0))
+		r.Finalize()
+		bells := strings.Count(out.String(), "\a")
+		if gotBell := strings.HasSuffix(out.String(), "\n\a"); gotBell != tt.wantBell || bells > 0 && !gotBell {
+			t.Errorf("tty %v, done %d: output %q, want bell %v", tt.tty, tt.done, out.String(), tt.wantBell)
+		}
+	}
+}
+
+func TestSpinnerFrames(t *testing.T) {
+	frames := []string{"⠋", "⠙", "⠹"}
+	var out bytes.Buffer
+	r := NewTextReporter().WithOutput(&out).WithLegend("{done_marker}\n").WithSpinnerFrames(frames)
+	for i := 0; i < 5; i++ {
+		r.Report(barReport(int64(i), 0, 0))
+	}
+	r.Report(barReport(100, 0, 0))
+	if got, want := out.String(), "⠋\n⠙\n⠹\n⠋\n⠙\n"+TextReporterDoneMarker+"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// with interval frames follow elapsed time, not the number of reports
+	r = NewTextReporter().WithLegend("{done_marker}").WithSpinnerFrames(frames).WithSpinnerInterval(100 * time.Millisecond)
+	for elapsed, want := range map[time.Duration]string{0: "⠋", 150 * time.Millisecond: "⠙", 250 * time.Millisecond: "⠹", 300 * time.Millisecond: "⠋"} {
+		if got := r.RenderString(NewReport(WithDone(1), WithTotal(10), WithElapsed(elapsed))); got != want {
+			t.Errorf("elapsed %v: got %q, want %q", elapsed, got, want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("empty frames do not panic")
+		}
+	}()
+	NewTextReporter().WithSpinnerFrames(nil)
+}
+
+func TestLeftBytes(t *testing.T) {
+	const mib = 1 << 20
+	r := NewTextReporter().WithLegend("{done_bytes} of {total_bytes}, {left_bytes} left")
+	report := NewReport(WithDone(58*mib), WithTotal(100*mib), WithReportUnit(UnitBytes))
+	if got, want := r.RenderString(report), "58.0 MiB of 100.0 MiB, 42.0 MiB left"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// more bytes than expected are never shown as negative left
+	report = NewReport(WithDone(120*mib), WithTotal(100*mib), WithReportUnit(UnitBytes))
+	if got, want := r.RenderString(report), "120.0 MiB of 100.0 MiB, 0 B left"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTimeZone(t *testing.T) {
+	report := NewReport(WithNow(time.Date(2023, 12, 2, 8, 52, 24, 0, time.FixedZone("CET", 3600))))
+
+	r := NewTextReporter().WithLegend("{now}")
+	if got, want := r.WithTimeZone(time.UTC).RenderString(report), "2023-12-02 07:52:24"; got != want {
+		t.Errorf("UTC: got %q, want %q", got, want)
+	}
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+	if got, want := r.WithTimeZone(newYork).RenderString(report), "2023-12-02 02:52:24"; got != want {
+		t.Errorf("America/New_York: got %q, want %q", got, want)
+	}
+}
+
+func TestCompletionMessage(t *testing.T) {
+	var out bytes.Buffer
+	r := NewTextReporter().WithOutput(&out).WithLegend("{done}/{total}\r").
+		WithCompletionMessage(func(report Report) string {
+			return "✓ Imported " + strconv.Itoa(report.Done) + " records in " + report.Elapsed.String()
+		})
+	r.Report(NewReport(WithDone(5000), WithTotal(10000), WithElapsed(time.Minute)))
+	r.Report(NewReport(WithDone(10000), WithTotal(10000), WithElapsed(2*time.Minute+13*time.Second)))
+	r.Finalize()
+
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) != 3 || lines[1] != "✓ Imported 10000 records in 2m13s" || lines[2] != "" {
+		t.Fatalf("got %q", out.String())
+	}
+
+	// the default summary is used only without a completion message
+	out.Reset()
+	r = NewTextReporter().WithOutput(&out).WithLegend("{done}/{total}\n").WithDefaultSummary(true)
+	r.Report(NewReport(WithDone(10000), WithTotal(10000), WithElapsed(2*time.Minute+13*time.Second)))
+	r.Finalize()
+	if !strings.HasSuffix(out.String(), "\nDone: 10,000 items in 2m13s\n") {
+		t.Fatalf("got %q", out.String())
+	}
+}
+
+func TestEraseLine(t *testing.T) {
+	var out bytes.Buffer
+	r := NewTextReporter().WithOutput(&out).WithForceTTY(true).WithLegend("{done}/{total}\r")
+	r.Report(NewReport(WithDone(1000), WithTotal(1000)))
+	// the shorter line overwrites the previous one with spaces
+	out.Reset()
+	r.Report(NewReport(WithDone(5), WithTotal(10)))
+	if want := "5/10     \r"; out.String() != want {
+		t.Fatalf("fallback: got %q, want %q", out.String(), want)
+	}
+
+	// terminal supporting escape sequences erases the rest of the line instead
+	r.ansi = true
+	r.Report(NewReport(WithDone(1000), WithTotal(1000)))
+	out.Reset()
+	r.Report(NewReport(WithDone(5), WithTotal(10)))
+	if want := "5/10" + eraseLineSequence + "\r"; out.String() != want {
+		t.Fatalf("terminal: got %q, want %q", out.String(), want)
+	}
+}
+
+func TestFieldAlign(t *testing.T) {
+	report := NewReport(WithDone(7), WithTotal(120))
+	tests := []struct {
+		name string
+		r    *TextReporter
+		want string
+	}{
+		{
+			name: "right",
+			r:    NewTextReporter().WithFieldAlign("{percent_int}", AlignRight, 4),
+			want: "|   5%| 7/120",
+		},
+		{
+			name: "left",
+			r:    NewTextReporter().WithFieldAlign("{percent_int}", AlignLeft, 4),
+			want: "|5   %| 7/120",
+		},
+		{
+			name: "both",
+			r: NewTextReporter().WithFieldAlign("{percent_int}", AlignLeft, 4).
+				WithFieldAlign("{done}", AlignRight, 5),
+			want: "|5   %|     7/120",
+		},
+		{
+			name: "longer value",
+			r:    NewTextReporter().WithFieldAlign("{total}", AlignRight, 2),
+			want: "|5%| 7/120",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.r.WithLegend("|{percent_int}%%| {done}/{total}").RenderString(report); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateStrategy(t *testing.T) {
+	tests := []struct {
+		strategy UpdateStrategy
+		want     string
+	}{
+		{strategy: UpdateCarriageReturn, want: "100/100\r5/10   \r\n"},
+		{strategy: UpdateBackspace, want: "100/100\b\b\b\b\b\b\b5/10   \n"},
+		{strategy: UpdateANSIClearLine, want: "100/100\r\x1b[K5/10\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		r := NewTextReporter().WithOutput(&out).WithLegend("{done}/{total}\r").WithUpdateStrategy(tt.strategy)
+		r.Report(NewReport(WithDone(100), WithTotal(100)))
+		r.Report(NewReport(WithDone(5), WithTotal(10)))
+		r.Finalize()
+		if out.String() != tt.want {
+			t.Errorf("strategy %d: got %q, want %q", tt.strategy, out.String(), tt.want)
+		}
+	}
+}
+
+func TestAdaptiveETARounding(t *testing.T) {
+	tests := []struct {
+		eta      time.Duration
+		adaptive string
+		plain    string
+	}{
+		{eta: 4600 * time.Millisecond, adaptive: "5s", plain: "5s"},
+		{eta: 59*time.Second + 400*time.Millisecond, adaptive: "59s", plain: "59s"},
+		{eta: 3*time.Minute + 12*time.Second, adaptive: "3m10s", plain: "3m12s"},
+		{eta: 3*time.Minute + 13*time.Second, adaptive: "3m15s", plain: "3m13s"},
+		{eta: 3*time.Hour + 29*time.Second, adaptive: "3h0m0s", plain: "3h0m29s"},
+		{eta: 3*time.Hour + 31*time.Second, adaptive: "3h1m0s", plain: "3h0m31s"},
+	}
+	adaptive := NewTextReporter().WithLegend("{eta}").WithAdaptiveETARounding(true)
+	plain := NewTextReporter().WithLegend("{eta}")
+	for _, tt := range tests {
+		report := NewReport(WithTotal(100), WithETA(tt.eta))
+		if got := adaptive.RenderString(report); got != tt.adaptive {
+			t.Errorf("adaptive ETA %v: got %q, want %q", tt.eta, got, tt.adaptive)
+		}
+		if got := plain.RenderString(report); got != tt.plain {
+			t.Errorf("ETA %v: got %q, want %q", tt.eta, got, tt.plain)
+		}
+	}
+}
+
+func TestRPSScaled(t *testing.T) {
+	report := NewReport(WithDone(51234560), WithTotal(100000000), WithElapsed(10*time.Second))
+	if got := NewTextReporter().WithLegend("{rps_scaled} {rps_avg}").RenderString(report); got != "5.1M/s 5123456.00" {
+		t.Fatalf("got %q", got)
+	}
+	got := NewTextReporter().WithLegend("{rps_scaled} {rps_avg}").WithRPSScaled(true).RenderString(report)
+	if got != "5.1M/s 5.1M/s" {
+		t.Fatalf("got %q with scaled RPS", got)
+	}
+}
+
+func TestTickMarks(t *testing.T) {
+	// 40 cells of the bar get ticks at cells 10, 20 and 30
+	r := NewTextReporter().WithLegend("{progress_bar}").WithProgressBarWidth(42).
+		WithTickMarks([]float64{0.25, 0.5, 0.75}, '|')
+	tests := []struct {
+		done int64
+		want string
+	}{
+		{done: 0, want: "[----------|---------|---------|---------]"},
+		{done: 30, want: "[############--------|---------|---------]"},
+		{done: 60, want: "[########################------|---------]"},
+		{done: 100, want: "[########################################]"},
+	}
+	for _, tt := range tests {
+		if got := r.RenderString(barReport(tt.done, 0, 0)); got != tt.want {
+			t.Errorf("%d%%: got %q, want %q", tt.done, got, tt.want)
+		}
+	}
+}
+
+// countingWriter counts writes to the underlying buffer
+type countingWriter struct {
+	writes int
+	buf    bytes.Buffer
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.buf.Write(p)
+}
+
+func TestFlushOnFinalizeOnly(t *testing.T) {
+	tests := []struct {
+		finalizeOnly bool
+		writes       int
+	}{
+		// every report is flushed
+		{finalizeOnly: false, writes: 100},
+		{finalizeOnly: true, writes: 1},
+	}
+	for _, tt := range tests {
+		out := &countingWriter{}
+		r := NewTextReporter().WithOutput(out).WithLegend("{done}/{total}\n").WithFlushOnFinalizeOnly(tt.finalizeOnly)
+		for done := int64(1); done <= 100; done++ {
+			r.Report(NewReport(WithDone(done), WithTotal(100)))
+		}
+		if tt.finalizeOnly && out.writes != 0 {
+			t.Errorf("%d writes before Finalize", out.writes)
+		}
+		r.Finalize()
+
+		if out.writes != tt.writes {
+			t.Errorf("finalize only %v: %d writes, want %d", tt.finalizeOnly, out.writes, tt.writes)
+		}
+		if lines := strings.Count(out.buf.String(), "\n"); lines != 100 {
+			t.Errorf("finalize only %v: %d lines written, want 100", tt.finalizeOnly, lines)
+		}
+	}
+}
+
+func TestDefaultSummary(t *testing.T) {
+	var out bytes.Buffer
+	r := NewTextReporter().WithOutput(&out).WithLegend("{done}/{total}\r").WithDefaultSummary(true)
+	r.Report(NewReport(WithDone(1), WithTotal(1), WithElapsed(3*time.Second)))
+	r.Finalize()
+	if want := "1/1\r\nDone: 1 item in 3s\n"; out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+
+	// the summary is opt-in
+	out.Reset()
+	r = NewTextReporter().WithOutput(&out).WithLegend("{done}/{total}\r")
+	r.Report(NewReport(WithDone(1), WithTotal(1), WithElapsed(3*time.Second)))
+	r.Finalize()
+	if want := "1/1\r\n"; out.String() != want {
+		t.Fatalf("got %q without summary, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,57 @@
+package gopv
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// barWidth returns the width of the progress bar in the rendered legend
+func barWidth(legend string) int {
+	return strings.LastIndex(legend, "]") - strings.Index(legend, "[") + 1
+}
+
+func TestRefreshOnResize(t *testing.T) {
+	terminal := openTerminal(t, 60)
+	r := NewTextReporter().WithOutput(terminal).WithRefreshOnResize(true).
+		WithProgressBarWidth(80).WithLegend("working {progress_bar} {done}/{total}\r")
+
+	// the line is 60 columns wide, the last one is left empty
+	r.Report(sampleReport())
+	if got, want := barWidth(r.lastLegend), 60-len("working  30/120")-1; got != want {
+		t.Fatalf("bar is %d columns wide in %q, want %d", got, r.lastLegend, want)
+	}
+
+	resizeTerminal(t, terminal, 40)
+	// force recompile as if SIGWINCH was received
+	atomic.StoreInt32(&r.resized, 1)
+	r.Report(sampleReport())
+	if got, want := barWidth(r.lastLegend), 40-len("working  30/120")-1; got != want {
+		t.Fatalf("bar is %d columns wide in %q after resize, want %d", got, r.lastLegend, want)
+	}
+
+	resizeTerminal(t, terminal, 200)
+	atomic.StoreInt32(&r.resized, 1)
+	r.Report(sampleReport())
+	if got := barWidth(r.lastLegend); got != 80 {
+		t.Fatalf("bar is %d columns wide in %q on a wide terminal, want 80", got, r.lastLegend)
+	}
+}
+
+func TestRefreshOnResizeMessageGivesWay(t *testing.T) {
+	terminal := openTerminal(t, 60)
+	r := NewTextReporter().WithOutput(terminal).WithRefreshOnResize(true).
+		WithProgressBarWidth(30).WithMessageTruncate(TruncateEnd, 0).
+		WithLegend("{progress_bar} {message}\r")
+
+	report := sampleReport()
+	report.Message = strings.Repeat("x", 100)
+	r.Report(report)
+	line := strings.TrimRight(r.lastLegend, "\r")
+	if got := barWidth(line); got != 30 {
+		t.Errorf("bar is %d columns wide in %q, want 30", got, line)
+	}
+	if got := displayWidth(line); got != 59 {
+		t.Errorf("line is %d columns wide, want 59: %q", got, line)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris)
+
+package gopv
+
+// watchResize is a no-op on platforms without SIGWINCH
+func watchResize(fn func()) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,39 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package gopv
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleSignalsRestoresDefault(t *testing.T) {
+	if os.Getenv("GOPV_SIGNAL_CHILD") == "1" {
+		pv := New(100).WithReporter(NewNullReporter())
+		StartChan(pv, make(chan struct{}))
+		pv.HandleSignals(syscall.SIGTERM)
+
+		_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		<-pv.Done()
+		// the handler is uninstalled, so the second signal terminates the process
+		_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		time.Sleep(5 * time.Second)
+		os.Exit(0)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHandleSignalsRestoresDefault$")
+	cmd.Env = append(os.Environ(), "GOPV_SIGNAL_CHILD=1")
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("process is not terminated by the second signal: %v", err)
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() || status.Signal() != syscall.SIGTERM {
+		t.Fatalf("process exited with %v, want termination by SIGTERM", err)
+	}
+}
@@ -0,0 +1,47 @@
+package gopv
+
+import (
+	"net/http"
+	"os"
+)
+
+// SizeProvider is implemented by sources which know their size. ok is false when size is unknown
+type SizeProvider interface {
+	Size() (size int64, ok bool)
+}
+
+// NewFromSource creates new progress tracker with total equal to the size of given source.
+// Supported sources are SizeProvider, files (anything with Stat() (os.FileInfo, error)),
+// readers with Size() int64 like *bytes.Reader and *strings.Reader, buffers with Len() int and
+// HTTP responses with Content-Length. When size is unknown or zero, indeterminate progress tracker
+// is returned and ok is false
+func NewFromSource(src any) (p *Progress, ok bool) {
+	size, ok := sourceSize(src)
+	if !ok || size <= 0 {
+		return NewIndeterminate(), false
+	}
+
+	return NewInt64(size), true
+}
+
+// sourceSize returns size of the source if it is known
+func sourceSize(src any) (int64, bool) {
+	switch s := src.(type) {
+	case SizeProvider:
+		return s.Size()
+	case *http.Response:
+		return s.ContentLength, s.ContentLength >= 0
+	case interface{ Stat() (os.FileInfo, error) }:
+		info, err := s.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return 0, false
+		}
+		return info.Size(), true
+	case interface{ Size() int64 }:
+		return s.Size(), true
+	case interface{ Len() int }:
+		return int64(s.Len()), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,50 @@
+package gopv
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewFromSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, make([]byte, 1234), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		name  string
+		src   any
+		total int64
+		ok    bool
+	}{
+		{name: "file", src: f, total: 1234, ok: true},
+		{name: "bytes.Reader", src: bytes.NewReader(make([]byte, 42)), total: 42, ok: true},
+		{name: "strings.Reader", src: strings.NewReader("hello"), total: 5, ok: true},
+		{name: "bytes.Buffer", src: bytes.NewBufferString("abc"), total: 3, ok: true},
+		{name: "http.Response", src: &http.Response{ContentLength: 3 << 30}, total: 3 << 30, ok: true},
+		{name: "http.Response without Content-Length", src: &http.Response{ContentLength: -1}},
+		{name: "empty reader", src: bytes.NewReader(nil)},
+		{name: "unknown", src: io.MultiReader()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pv, ok := NewFromSource(tt.src)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if total := pv.Snapshot().Total64; total != tt.total {
+				t.Fatalf("total = %d, want %d", total, tt.total)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package gopv
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+// progressState is the persisted state of a progress tracker, see MarshalState
+type progressState struct {
+	Done      int64         `json:"done"`
+	Total     int64         `json:"total"`
+	Errors    int64         `json:"errors"`
+	Phase     string        `json:"phase"`
+	Unit      string        `json:"unit"`
+	StartedAt time.Time     `json:"started_at"`
+	Elapsed   time.Duration `json:"elapsed"`
+}
+
+// MarshalState serializes counters and elapsed time of the progress tracker, so a job which may
+// be killed can be resumed later with LoadState. The reporter and options are not serialized
+func (p *Progress) MarshalState() ([]byte, error) {
+	c := p.loadCounters()
+	startedAt := p.loadLoopState().startedAt
+	state := progressState{
+		Done:      c.done,
+		Total:     c.total,
+		Errors:    c.errors,
+		Phase:     c.phase,
+		Unit:      p.unit,
+		StartedAt: startedAt,
+		Elapsed:   p.resumedElapsed,
+	}
+	if atomic.LoadInt32(&p.started) == 1 {
+		state.Elapsed = since(p.now(), startedAt)
+	}
+
+	return json.Marshal(state)
+}
+
+// LoadState returns a new progress tracker restored from the state serialized by MarshalState.
+// Once started, its elapsed time continues from the saved one, so rates and ETA do not count the
+// time the job was not running. Reporter and options should be set again after loading
+func LoadState(data []byte) (*Progress, error) {
+	var state progressState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	p := NewIndeterminate()
+	p.unit = state.Unit
+	p.resumedElapsed = state.Elapsed
+	p.done = state.Done
+	p.counters.Store(&counters{
+		total:  state.Total,
+		errors: state.Errors,
+		phase:  state.Phase,
+	})
+
+	return p, nil
+}
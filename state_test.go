@@ -0,0 +1,51 @@
+package gopv
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	clock := newManualClock()
+	pv := New(120).WithReporter(NewNullReporter()).WithClock(clock.Now).WithUnit(UnitBytes)
+	startManual(t, pv)
+	pv.SetPhase("download")
+	clock.Advance(10 * time.Second)
+	pv.Add(28)
+	pv.AddError(2)
+
+	data, err := pv.MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the job is restarted much later, the time it was not running is not counted
+	clock.Advance(time.Hour)
+	loaded, err := LoadState(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded = loaded.WithReporter(NewNullReporter()).WithClock(clock.Now)
+	startManual(t, loaded)
+
+	report := loaded.Snapshot()
+	if report.Done != 30 || report.Total != 120 || report.Errors != 2 || report.Phase != "download" || report.Unit != UnitBytes {
+		t.Fatalf("restored %d/%d, %d errors, phase %q, unit %q", report.Done, report.Total, report.Errors, report.Phase, report.Unit)
+	}
+	if report.Elapsed != 10*time.Second {
+		t.Fatalf("restored elapsed %v, want 10s", report.Elapsed)
+	}
+
+	clock.Advance(10 * time.Second)
+	loaded.Add(30)
+	report = loaded.Snapshot()
+	if report.Elapsed != 20*time.Second || report.RPSAvg != 3 || report.ETA != 20*time.Second {
+		t.Fatalf("elapsed %v, RPS %v, ETA %v, want 20s, 3 and 20s", report.Elapsed, report.RPSAvg, report.ETA)
+	}
+}
+
+func TestLoadStateInvalid(t *testing.T) {
+	if _, err := LoadState([]byte("{")); err == nil {
+		t.Fatal("no error for invalid state")
+	}
+}
@@ -0,0 +1,110 @@
+package gopv
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// runningStdDev computes standard deviation of a sequence online using Welford's algorithm
+type runningStdDev struct {
+	n    int64
+	mean float64
+	m2   float64
+}
+
+// add adds a sample to the sequence. NaN and infinite samples are ignored
+func (s *runningStdDev) add(x float64) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return
+	}
+
+	s.n++
+	delta := x - s.mean
+	s.mean += delta / float64(s.n)
+	s.m2 += delta * (x - s.mean)
+}
+
+// stdDev returns sample standard deviation. Zero for less than two samples
+func (s *runningStdDev) stdDev() float64 {
+	if s.n < 2 {
+		return 0
+	}
+	return math.Sqrt(s.m2 / float64(s.n-1))
+}
+
+// rateSample is a value of done counter at some moment
+type rateSample struct {
+	at   time.Time
+	done int64
+}
+
+// rateWindow keeps samples of done counter over a sliding time window
+type rateWindow struct {
+	mu      sync.Mutex
+	width   time.Duration
+	samples []rateSample
+}
+
+// add adds a sample and drops samples which went out of the window
+func (w *rateWindow) add(at time.Time, done int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.samples = append(w.samples, rateSample{at: at, done: done})
+
+	// keep one sample older than the window, so the window is always covered
+	drop := 0
+	for drop+1 < len(w.samples) && at.Sub(w.samples[drop+1].at) >= w.width {
+		drop++
+	}
+	w.samples = w.samples[drop:]
+}
+
+// rate returns rate between the oldest sample in the window and given moment.
+// ok is false when there are no samples yet
+func (w *rateWindow) rate(now time.Time, done int64) (rps float64, ok bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.samples) == 0 {
+		return 0, false
+	}
+
+	oldest := w.samples[0]
+	return perSecond(done-oldest.done, since(now, oldest.at)), true
+}
+
+// rateHistory keeps the last samples of instant rate in a ring buffer
+type rateHistory struct {
+	mu      sync.Mutex
+	samples []float64
+	next    int
+	full    bool
+}
+
+// add adds a sample overwriting the oldest one when the buffer is full
+func (h *rateHistory) add(rps float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.samples[h.next] = rps
+	h.next++
+	if h.next == len(h.samples) {
+		h.next = 0
+		h.full = true
+	}
+}
+
+// values returns a copy of samples from the oldest to the newest one
+func (h *rateHistory) values() []float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.full {
+		return append([]float64(nil), h.samples[:h.next]...)
+	}
+	values := make([]float64, 0, len(h.samples))
+	values = append(values, h.samples[h.next:]...)
+	return append(values, h.samples[:h.next]...)
+}
@@ -0,0 +1,50 @@
+package gopv
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRunningStdDev(t *testing.T) {
+	var s runningStdDev
+	if got := s.stdDev(); got != 0 {
+		t.Fatalf("stddev of no samples = %v, want 0", got)
+	}
+
+	for _, rate := range []float64{2, 4, 4, 4, 5, 5, 7, 9, math.NaN(), math.Inf(1)} {
+		s.add(rate)
+	}
+	// sum of squared deviations from mean 5 is 32 over 8 samples
+	if got, want := s.stdDev(), math.Sqrt(32.0/7); math.Abs(got-want) > 1e-12 {
+		t.Fatalf("stddev = %v, want %v", got, want)
+	}
+
+	report := NewReport()
+	report.RPSStdDev = s.stdDev()
+	if got := NewTextReporter().WithLegend("{rps_stddev}").RenderString(report); got != "2.14" {
+		t.Fatalf("{rps_stddev} = %q, want %q", got, "2.14")
+	}
+}
+
+func TestRateHistory(t *testing.T) {
+	h := &rateHistory{samples: make([]float64, 3)}
+	if got := h.values(); len(got) != 0 {
+		t.Fatalf("empty history has values %v", got)
+	}
+
+	want := [][]float64{{1}, {1, 2}, {1, 2, 3}, {2, 3, 4}, {3, 4, 5}}
+	for i, w := range want {
+		h.add(float64(i + 1))
+		if got := h.values(); !reflect.DeepEqual(got, w) {
+			t.Fatalf("after %d samples: got %v, want %v", i+1, got, w)
+		}
+	}
+
+	if New(10).RateHistory() != nil {
+		t.Fatal("rate history is kept without WithRateHistory")
+	}
+	if got := New(10).WithRateHistory(3).RateHistory(); len(got) != 0 {
+		t.Fatalf("history of a tracker which did not report has values %v", got)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pavel-krush/gopv"
+	"github.com/pavel-krush/gopv/teamodel"
+)
+
+// app quits on any key and embeds the progress model
+type app struct {
+	progress teamodel.Model
+}
+
+func (a app) Init() tea.Cmd {
+	return a.progress.Init()
+}
+
+func (a app) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(tea.KeyMsg); ok {
+		return a, tea.Quit
+	}
+	if a.progress.Progress().Report().IsComplete {
+		return a, tea.Quit
+	}
+
+	m, cmd := a.progress.Update(msg)
+	a.progress = m.(teamodel.Model)
+	return a, cmd
+}
+
+func (a app) View() string {
+	return "Working, press any key to quit\n\n" + a.progress.View() + "\n"
+}
+
+func main() {
+	const total = 50
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	progress := teamodel.New(ctx, gopv.New(total), gopv.NewTextReporter().WithLegend(gopv.TextReporterLegendProgressBar).WithProgressBarWidth(40))
+	p := tea.NewProgram(app{progress: progress})
+
+	go func() {
+		for i := 0; i < total; i++ {
+			<-time.After(time.Millisecond * 100)
+			p.Send(teamodel.ProgressMsg{Done: 1})
+		}
+	}()
+
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,81 @@
+// Package teamodel provides a bubbletea model rendering gopv progress. It lives in a separate
+// module, so programs which do not use bubbletea do not depend on it.
+package teamodel
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pavel-krush/gopv"
+)
+
+// DefaultInterval is the default interval between renders of the model
+const DefaultInterval = 100 * time.Millisecond
+
+// ProgressMsg advances the progress by Done items
+type ProgressMsg struct {
+	Done int
+}
+
+// TickMsg makes the model render the current progress
+type TickMsg time.Time
+
+// Model is a bubbletea model rendering progress tracker with TextReporter legend
+type Model struct {
+	pv       *gopv.Progress
+	reporter *gopv.TextReporter
+	interval time.Duration
+}
+
+// New returns a model tracking the progress of pv until ctx is done. The model renders the
+// progress itself, so reports of pv are discarded. Use Progress to advance it from outside of
+// bubbletea, e.g. from worker goroutines
+func New(ctx context.Context, pv *gopv.Progress, reporter *gopv.TextReporter) Model {
+	pv = pv.WithReporter(gopv.NewNullReporter())
+	gopv.StartCtx(pv, ctx)
+
+	return Model{
+		pv:       pv,
+		reporter: reporter,
+		interval: DefaultInterval,
+	}
+}
+
+// WithInterval returns a copy of the model rendering every d
+func (m Model) WithInterval(d time.Duration) Model {
+	m.interval = d
+	return m
+}
+
+// Progress returns the tracked progress
+func (m Model) Progress() *gopv.Progress {
+	return m.pv
+}
+
+// Init starts rendering ticks
+func (m Model) Init() tea.Cmd {
+	return m.tick()
+}
+
+// Update advances the progress on ProgressMsg and schedules the next tick on TickMsg
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case ProgressMsg:
+		m.pv.Add(msg.Done)
+	case TickMsg:
+		return m, m.tick()
+	}
+	return m, nil
+}
+
+// View renders the current progress
+func (m Model) View() string {
+	return m.reporter.RenderString(m.pv.Snapshot())
+}
+
+func (m Model) tick() tea.Cmd {
+	return tea.Tick(m.interval, func(t time.Time) tea.Msg {
+		return TickMsg(t)
+	})
+}
@@ -0,0 +1,63 @@
+package teamodel
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pavel-krush/gopv"
+)
+
+func newModel(t *testing.T, total int) Model {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return New(ctx, gopv.New(total), gopv.NewTextReporter().WithLegend("{done}/{total} {percent_int}%%"))
+}
+
+func TestUpdateView(t *testing.T) {
+	var m tea.Model = newModel(t, 10)
+	if got, want := m.View(), "0/10 0%"; got != want {
+		t.Fatalf("initial view = %q, want %q", got, want)
+	}
+
+	m, cmd := m.Update(ProgressMsg{Done: 3})
+	if cmd != nil {
+		t.Fatal("ProgressMsg should not schedule a command")
+	}
+	if got, want := m.View(), "3/10 30%"; got != want {
+		t.Fatalf("view = %q, want %q", got, want)
+	}
+
+	m, cmd = m.Update(TickMsg(time.Now()))
+	if cmd == nil {
+		t.Fatal("TickMsg should schedule the next tick")
+	}
+	if got, want := m.View(), "3/10 30%"; got != want {
+		t.Fatalf("view after tick = %q, want %q", got, want)
+	}
+}
+
+func TestViewConcurrentWithWorkers(t *testing.T) {
+	m := newModel(t, 1000)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 250; j++ {
+				m.Progress().Add(1)
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		_ = m.View()
+	}
+	wg.Wait()
+
+	if got, want := m.View(), "1000/1000 100%"; got != want {
+		t.Fatalf("view = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,146 @@
+package gopv
+
+import (
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// outputFile returns file behind given writer
+func outputFile(w io.Writer) (*os.File, bool) {
+	switch w := w.(type) {
+	case *os.File:
+		return w, true
+	case *lockedWriter:
+		return outputFile(w.w)
+	}
+	return nil, false
+}
+
+// isTerminal reports whether given writer is a terminal
+func isTerminal(w io.Writer) bool {
+	f, isFile := outputFile(w)
+	return isFile && term.IsTerminal(int(f.Fd()))
+}
+
+// supportsANSI reports whether given writer is a terminal which understands ANSI escape sequences.
+// Features relying on colors or cursor movement should degrade to plain output otherwise
+func supportsANSI(w io.Writer) bool {
+	return isTerminal(w) && enableVirtualTerminal(w)
+}
+
+// terminalWidth returns width of the terminal behind given writer.
+// ok is false when writer is not a terminal or size can not be determined
+func terminalWidth(w io.Writer) (width int, ok bool) {
+	width, _, ok = terminalSize(w)
+	return width, ok
+}
+
+// terminalSize returns size of the terminal behind given writer.
+// ok is false when writer is not a terminal or size can not be determined
+func terminalSize(w io.Writer) (width, height int, ok bool) {
+	f, isFile := outputFile(w)
+	if !isFile {
+		return 0, 0, false
+	}
+
+	width, height, err := term.GetSize(int(f.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+
+	return width, height, true
+}
+
+// displayWidth returns number of columns s takes in a terminal ignoring ANSI escape sequences
+func displayWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '[' {
+			// skip CSI sequence up to the final byte
+			i += 2
+			for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+				i++
+			}
+			i++
+			continue
+		}
+
+		ru, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		width += runeWidth(ru)
+	}
+
+	return width
+}
+
+// wideRunes are ranges of runes taking two columns: East Asian wide characters and emoji
+var wideRunes = [][2]rune{
+	{0x1100, 0x115f},
+	{0x231a, 0x231b},
+	{0x23e9, 0x23ec},
+	{0x23f0, 0x23f0},
+	{0x23f3, 0x23f3},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267f, 0x267f},
+	{0x2693, 0x2693},
+	{0x26a1, 0x26a1},
+	{0x26aa, 0x26ab},
+	{0x26bd, 0x26be},
+	{0x26c4, 0x26c5},
+	{0x26ce, 0x26ce},
+	{0x26d4, 0x26d4},
+	{0x26ea, 0x26ea},
+	{0x26f2, 0x26f3},
+	{0x26f5, 0x26f5},
+	{0x26fa, 0x26fa},
+	{0x26fd, 0x26fd},
+	{0x2705, 0x2705},
+	{0x270a, 0x270b},
+	{0x2728, 0x2728},
+	{0x274c, 0x274c},
+	{0x274e, 0x274e},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27b0, 0x27b0},
+	{0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c},
+	{0x2b50, 0x2b50},
+	{0x2b55, 0x2b55},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe30, 0xfe4f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x1f004, 0x1f004},
+	{0x1f0cf, 0x1f0cf},
+	{0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff},
+	{0x1f7e0, 0x1f7eb},
+	{0x1f900, 0x1f9ff},
+	{0x1fa70, 0x1faff},
+	{0x20000, 0x3fffd},
+}
+
+// runeWidth returns the number of columns the rune takes in a terminal
+func runeWidth(r rune) int {
+	if r < 0x1100 {
+		return 1
+	}
+	for _, rng := range wideRunes {
+		if r >= rng[0] && r <= rng[1] {
+			return 2
+		}
+	}
+	return 1
+}
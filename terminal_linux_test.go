@@ -0,0 +1,109 @@
+package gopv
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// openTerminal opens a pseudo-terminal of given width and returns its slave side. Output written
+// to the terminal is discarded
+func openTerminal(t *testing.T, width int) *os.File {
+	terminal, _ := captureTerminal(t, width)
+	return terminal
+}
+
+// captureTerminal opens a pseudo-terminal of given width and returns its slave side. Output written
+// to the terminal is returned by output, which closes the terminal
+func captureTerminal(t *testing.T, width int) (terminal *os.File, output func() string) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	t.Cleanup(func() { _ = master.Close() })
+	if err := unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0); err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	n, err := unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	slave, err := os.OpenFile("/dev/pts/"+strconv.Itoa(n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	t.Cleanup(func() { _ = slave.Close() })
+	var out bytes.Buffer
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		// reading fails once the slave side is closed
+		_, _ = io.Copy(&out, master)
+	}()
+
+	resizeTerminal(t, slave, width)
+	return slave, func() string {
+		_ = slave.Close()
+		<-copied
+		return out.String()
+	}
+}
+
+// resizeTerminal changes width of the terminal
+func resizeTerminal(t *testing.T, f *os.File, width int) {
+	ws := &unix.Winsize{Col: uint16(width), Row: 24}
+	if err := unix.IoctlSetWinsize(int(f.Fd()), unix.TIOCSWINSZ, ws); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFullscreen(t *testing.T) {
+	terminal, output := captureTerminal(t, 40)
+	r := NewTextReporter().WithOutput(terminal).WithFullscreen(true).WithLegend("{done}/{total}\r")
+	r.Report(NewReport(WithDone(5), WithTotal(10)))
+	r.Report(NewReport(WithDone(10), WithTotal(10)))
+	r.Finalize()
+
+	// the screen is cleared and the cursor moved home first, the line is drawn centered
+	out := output()
+	if !strings.HasPrefix(out, "\x1b[?1049h\x1b[2J\x1b[H") {
+		t.Fatalf("screen is not cleared at start: %q", out)
+	}
+	if !strings.Contains(out, "\x1b[2J\x1b[12;18H10/10") {
+		t.Fatalf("line is not centered: %q", out)
+	}
+	// the main screen is restored at finalize and the last line is left on it
+	if !strings.HasSuffix(out, "\x1b[?1049l10/10\r\r\n") {
+		t.Fatalf("screen is not restored at finalize: %q", out)
+	}
+
+	// other outputs are left alone
+	var plain bytes.Buffer
+	r = NewTextReporter().WithOutput(&plain).WithFullscreen(true).WithLegend("{done}/{total}\r")
+	r.Report(NewReport(WithDone(10), WithTotal(10)))
+	r.Finalize()
+	if plain.String() != "10/10\r\n" {
+		t.Fatalf("got %q from fullscreen reporter writing to a buffer", plain.String())
+	}
+}
+
+func TestStickyBottom(t *testing.T) {
+	terminal, output := captureTerminal(t, 40)
+	r := NewTextReporter().WithOutput(terminal).WithStickyBottom(true).WithLegend("{done}/{total}\r")
+	r.Report(NewReport(WithDone(5), WithTotal(10)))
+	r.Finalize()
+
+	// the last of 24 lines is reserved for the legend, which is drawn there keeping the cursor
+	// in place, and released at finalize
+	want := "\r\n\x1b[1A" + "\x1b7\x1b[1;23r\x1b8" +
+		"\x1b7\x1b[24;1H\x1b[2K5/10\r\x1b8" +
+		"\x1b7\x1b[24;1H\x1b[2K\x1b[r\x1b8" + "5/10\r\r\n"
+	if got := output(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
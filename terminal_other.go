@@ -0,0 +1,11 @@
+//go:build !windows
+
+package gopv
+
+import "io"
+
+// enableVirtualTerminal reports whether ANSI escape sequences are supported. Terminals on
+// platforms other than windows are expected to support them
+func enableVirtualTerminal(w io.Writer) bool {
+	return true
+}
@@ -0,0 +1,32 @@
+package gopv
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestSupportsANSI(t *testing.T) {
+	if supportsANSI(&bytes.Buffer{}) {
+		t.Fatal("buffer supports ANSI escape sequences")
+	}
+}
+
+func TestPlainFallback(t *testing.T) {
+	// output forced to be a terminal which does not support escape sequences gets plain \r updates
+	var out bytes.Buffer
+	r := NewTextReporter().WithOutput(&out).WithForceTTY(true).WithLegend("{progress_bar}\r").
+		WithProgressBarWidth(12).WithGradient(color.RGBA{B: 255, A: 255}, color.RGBA{G: 255, A: 255}).
+		WithStickyBottom(true)
+	r.Report(barReport(50, 0, 0))
+	r.Report(barReport(100, 0, 0))
+	r.Finalize()
+
+	if strings.Contains(out.String(), "\x1b") {
+		t.Fatalf("escape sequences are written: %q", out.String())
+	}
+	if want := "[#####-----]\r[##########]\r\n"; out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package gopv
+
+import (
+	"io"
+
+	"golang.org/x/sys/windows"
+)
+
+// enableVirtualTerminal turns on processing of ANSI escape sequences for the console behind
+// given writer. Returns false on legacy consoles without VT support
+func enableVirtualTerminal(w io.Writer) bool {
+	f, isFile := outputFile(w)
+	if !isFile {
+		return false
+	}
+
+	handle := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return false
+	}
+
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+
+	return windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package gopv
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnableVirtualTerminalWithoutConsole(t *testing.T) {
+	if enableVirtualTerminal(&bytes.Buffer{}) {
+		t.Fatal("VT processing is enabled for a buffer")
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if enableVirtualTerminal(f) {
+		t.Fatal("VT processing is enabled for a regular file")
+	}
+}
@@ -0,0 +1,68 @@
+package gopv
+
+import (
+	"io"
+	"time"
+)
+
+// TimestampFormat is the format of line prefixes written by WithTimestampWriter
+const TimestampFormat = time.RFC3339
+
+// timestampWriter prefixes every line written to the underlying writer with a timestamp
+type timestampWriter struct {
+	w           io.Writer
+	atLineStart bool
+	afterCR     bool
+}
+
+// WithTimestampWriter returns a writer which prefixes every line written to w with current time
+// in TimestampFormat. Lines end with \n or \r, so in-place progress lines get fresh timestamps too.
+// It can be used as the output of any writer based reporter
+func WithTimestampWriter(w io.Writer) io.Writer {
+	return &timestampWriter{w: w, atLineStart: true}
+}
+
+// Write writes p prefixing lines which start in it
+func (t *timestampWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if t.afterCR && p[0] == '\n' {
+			// \r\n ends a single line
+			n, err := t.w.Write(p[:1])
+			written += n
+			if err != nil {
+				return written, err
+			}
+			t.afterCR = false
+			p = p[1:]
+			continue
+		}
+
+		if t.atLineStart {
+			if _, err := io.WriteString(t.w, time.Now().Format(TimestampFormat)+" "); err != nil {
+				return written, err
+			}
+			t.atLineStart = false
+			t.afterCR = false
+		}
+
+		end := len(p)
+		for i, b := range p {
+			if b == '\n' || b == '\r' {
+				end = i + 1
+				t.atLineStart = true
+				t.afterCR = b == '\r'
+				break
+			}
+		}
+
+		n, err := t.w.Write(p[:end])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[end:]
+	}
+
+	return written, nil
+}
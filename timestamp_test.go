@@ -0,0 +1,30 @@
+package gopv
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestTimestampWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := WithTimestampWriter(&out)
+	for _, chunk := range []string{"a\nb", "c\r\n", "d\r", "e\n"} {
+		if n, err := w.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("wrote %d bytes of %q: %v", n, chunk, err)
+		}
+	}
+
+	timestamp := regexp.MustCompile(`\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(Z|[+-]\d\d:\d\d) `)
+	for _, ts := range timestamp.FindAllString(out.String(), -1) {
+		if _, err := time.Parse(TimestampFormat+" ", ts); err != nil {
+			t.Fatalf("bad timestamp %q: %v", ts, err)
+		}
+	}
+	// lines are prefixed as they start, \r\n ends a single line
+	got := timestamp.ReplaceAllString(out.String(), "<ts> ")
+	if want := "<ts> a\n<ts> bc\r\n<ts> d\r<ts> e\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package gopv
+
+import "fmt"
+
+// TrackErr calls fn for each item. Successful items are reported with Add and failed ones with
+// AddError. Returns errors of all failed items in order, nil if none failed
+func TrackErr[T any](pv *Progress, items []T, fn func(T) error) []error {
+	var errs []error
+	for _, item := range items {
+		if err := fn(item); err != nil {
+			pv.AddError(1)
+			errs = append(errs, err)
+			continue
+		}
+		pv.Add(1)
+	}
+	return errs
+}
+
+// TrackMap calls fn for each entry of m and reports it with Add. Before fn is called the key is
+// passed to setKey, so it can be shown, e.g. with SetMessage. If setKey is nil, the key formatted
+// with fmt.Sprint is set as the message. Entries are visited in the map iteration order, which is
+// random
+func TrackMap[K comparable, V any](pv *Progress, m map[K]V, fn func(K, V), setKey func(K)) {
+	for k, v := range m {
+		if setKey != nil {
+			setKey(k)
+		} else {
+			pv.SetMessage(fmt.Sprint(k))
+		}
+		fn(k, v)
+		pv.Add(1)
+	}
+}
+
+// Group runs functions concurrently, e.g. *errgroup.Group from golang.org/x/sync/errgroup
+type Group interface {
+	Go(fn func() error)
+}
+
+// Go runs fn in g and reports it with Add when it succeeds or with AddError when it fails.
+// The error is returned to g as is
+func (p *Progress) Go(g Group, fn func() error) {
+	g.Go(func() error {
+		if err := fn(); err != nil {
+			p.AddError(1)
+			return err
+		}
+		p.Add(1)
+		return nil
+	})
+}
@@ -0,0 +1,116 @@
+package gopv
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestTrackErr(t *testing.T) {
+	pv := New(5).WithReporter(NewNullReporter())
+	startManual(t, pv)
+
+	errs := TrackErr(pv, []int{1, 2, 3, 4, 5}, func(n int) error {
+		if n%2 == 0 {
+			return fmt.Errorf("item %d failed", n)
+		}
+		return nil
+	})
+	if len(errs) != 2 || errs[0].Error() != "item 2 failed" || errs[1].Error() != "item 4 failed" {
+		t.Fatalf("errors %v, want errors of items 2 and 4", errs)
+	}
+
+	report := pv.Snapshot()
+	if report.Done != 5 || report.Errors != 2 || !report.IsComplete {
+		t.Fatalf("done %d, errors %d, complete %v, want 5, 2 and complete", report.Done, report.Errors, report.IsComplete)
+	}
+}
+
+func TestTrackErrWithoutErrors(t *testing.T) {
+	pv := New(2).WithReporter(NewNullReporter())
+	if errs := TrackErr(pv, []string{"a", "b"}, func(string) error { return nil }); errs != nil {
+		t.Fatalf("errors %v, want nil", errs)
+	}
+}
+
+func TestTrackMap(t *testing.T) {
+	m := map[string]int{"a": 1, "b": 2, "c": 3}
+	pv := New(len(m)).WithReporter(NewNullReporter())
+	startManual(t, pv)
+
+	// the key is set before its entry is processed, which is reported after
+	var current string
+	processed, sum := 0, 0
+	TrackMap(pv, m, func(k string, v int) {
+		if k != current {
+			t.Errorf("processing %q while the key is %q", k, current)
+		}
+		if done := pv.Snapshot().Done; done != processed {
+			t.Errorf("%d items done before %q, want %d", done, k, processed)
+		}
+		processed++
+		sum += v
+	}, func(k string) {
+		current = k
+	})
+
+	report := pv.Snapshot()
+	if report.Done != len(m) || !report.IsComplete || sum != 6 {
+		t.Fatalf("done %d, complete %v, sum %d, want %d, complete and 6", report.Done, report.IsComplete, sum, len(m))
+	}
+
+	// without setKey the key becomes the message
+	pv = New(1).WithReporter(NewNullReporter())
+	TrackMap(pv, map[int]bool{42: true}, func(int, bool) {}, nil)
+	if report := pv.Snapshot(); report.Message != "42" || report.Done != 1 {
+		t.Fatalf("message %q, done %d, want 42 and 1", report.Message, report.Done)
+	}
+}
+
+// waitGroup is a minimal Group which remembers the first error like errgroup.Group
+type waitGroup struct {
+	wg   sync.WaitGroup
+	once sync.Once
+	err  error
+}
+
+func (g *waitGroup) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := fn(); err != nil {
+			g.once.Do(func() { g.err = err })
+		}
+	}()
+}
+
+func (g *waitGroup) Wait() error {
+	g.wg.Wait()
+	return g.err
+}
+
+func TestGo(t *testing.T) {
+	pv := New(10).WithReporter(NewNullReporter())
+	startManual(t, pv)
+
+	errFailed := errors.New("failed")
+	g := &waitGroup{}
+	for i := 0; i < 10; i++ {
+		i := i
+		pv.Go(g, func() error {
+			if i%4 == 0 {
+				return errFailed
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != errFailed {
+		t.Fatalf("group error %v, want %v", err, errFailed)
+	}
+
+	report := pv.Snapshot()
+	if report.Done != 10 || report.Errors != 3 {
+		t.Fatalf("done %d, errors %d, want 10 and 3", report.Done, report.Errors)
+	}
+}
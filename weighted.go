@@ -0,0 +1,100 @@
+package gopv
+
+// WeightedScale is the total of WeightedProgress reports. Done is the weighted ratio multiplied by it
+const WeightedScale = 10000
+
+// WeightedProgress combines children of different cost into a single progress. Overall ratio is
+// the sum of children ratios multiplied by their weights, e.g. compiling with weight 0.7 and
+// linking with weight 0.3. Weights are normalized, so they do not have to sum up to 1.
+// Reports have Total equal to WeightedScale and Done proportional to the overall ratio
+type WeightedProgress struct {
+	aggregate *Progress
+	group     *progressGroup
+}
+
+// NewWeighted creates new weighted progress tracker without children
+func NewWeighted() *WeightedProgress {
+	wp := &WeightedProgress{
+		aggregate: New(1),
+		group:     &progressGroup{},
+	}
+	wp.aggregate.source = wp.group.weightedSum
+
+	return wp
+}
+
+// WithReporter returns a new instance of weighted progress tracker with custom reporter
+func (wp *WeightedProgress) WithReporter(r Reporter) *WeightedProgress {
+	cp := *wp
+	cp.aggregate = wp.aggregate.WithReporter(r)
+	return &cp
+}
+
+// NewChild creates new child progress tracker with given weight and registers it
+func (wp *WeightedProgress) NewChild(total int, weight float64) *Progress {
+	child := New(total)
+	wp.Register(child, weight)
+	return child
+}
+
+// Register adds child progress tracker with given weight. It is safe to register children while running
+func (wp *WeightedProgress) Register(child *Progress, weight float64) {
+	if weight <= 0 {
+		panic("weight should be greater than 0")
+	}
+	wp.group.add(child, weight)
+}
+
+// Report returns current weighted progress report
+func (wp *WeightedProgress) Report() Report {
+	return wp.aggregate.Report()
+}
+
+// Snapshot returns current weighted progress report without side effects
+func (wp *WeightedProgress) Snapshot() Report {
+	return wp.aggregate.Snapshot()
+}
+
+// Done returns a channel which is closed when the last report is rendered
+func (wp *WeightedProgress) Done() chan struct{} {
+	return wp.aggregate.Done()
+}
+
+// Wait blocks until the last report is rendered
+func (wp *WeightedProgress) Wait() {
+	wp.aggregate.Wait()
+}
+
+func (wp *WeightedProgress) progress() *Progress {
+	return wp.aggregate
+}
+
+// weightedSum returns counters scaled to WeightedScale according to children ratios and weights
+func (g *progressGroup) weightedSum() *counters {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var ratio, weights float64
+	for i, child := range g.children {
+		cc := child.loadCounters()
+		var childRatio float64
+		switch {
+		case cc.completed || cc.total > 0 && cc.done >= cc.total:
+			childRatio = 1
+		case cc.total > 0 && cc.done > 0:
+			childRatio = float64(cc.done) / float64(cc.total)
+		}
+
+		ratio += childRatio * g.weights[i]
+		weights += g.weights[i]
+	}
+
+	if weights == 0 {
+		return &counters{total: WeightedScale}
+	}
+
+	return &counters{
+		done:  int64(ratio / weights * WeightedScale),
+		total: WeightedScale,
+	}
+}
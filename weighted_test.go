@@ -0,0 +1,42 @@
+package gopv
+
+import "testing"
+
+func TestWeightedProgress(t *testing.T) {
+	wp := NewWeighted().WithReporter(NewNullReporter())
+	compile := wp.NewChild(200, 0.7)
+	link := wp.NewChild(10, 0.3)
+	stop := make(chan struct{})
+	StartChan(wp, stop)
+	defer func() {
+		close(stop)
+		wp.Wait()
+	}()
+
+	// half of compiling is 35% of the work
+	compile.Add(100)
+	if report := wp.Snapshot(); report.PercentInt != 35 {
+		t.Fatalf("%d%% done, want 35%%", report.PercentInt)
+	}
+
+	compile.Add(100)
+	link.Add(5)
+	if report := wp.Snapshot(); report.PercentInt != 85 || report.IsComplete {
+		t.Fatalf("%d%% done, complete %v, want 85%%", report.PercentInt, report.IsComplete)
+	}
+
+	link.Add(5)
+	if report := wp.Snapshot(); report.PercentInt != 100 || !report.IsComplete {
+		t.Fatalf("%d%% done, complete %v, want complete", report.PercentInt, report.IsComplete)
+	}
+}
+
+func TestWeightedProgressNormalizesWeights(t *testing.T) {
+	wp := NewWeighted().WithReporter(NewNullReporter())
+	a := wp.NewChild(10, 3)
+	wp.NewChild(10, 1)
+	a.Add(10)
+	if report := wp.Snapshot(); report.PercentInt != 75 {
+		t.Fatalf("%d%% done, want 75%%", report.PercentInt)
+	}
+}